@@ -15,11 +15,14 @@ import (
 	"fmt"
 	"io/ioutil"
 	"log"
+	"math"
 	"os"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/DavidGamba/csv-analysis/csvutil"
+	"github.com/DavidGamba/csv-analysis/output"
 	"github.com/DavidGamba/csv-analysis/regression"
 	"github.com/DavidGamba/csv-analysis/stat"
 	"github.com/DavidGamba/go-getoptions"
@@ -35,6 +38,197 @@ var noHeader bool
 // filterZero - Ignore 0 value entries from statistical calculations.
 var filterZero bool
 
+// dialect - The format of the csv files to be read.
+var dialect csvutil.Dialect
+
+// missing - How to handle records with missing, unparsable or zero filtered values.
+var missing csvutil.MissingPolicy
+
+// headers - How to handle files with a header that differs from the header of the first file.
+var headers csvutil.HeaderPolicy
+
+// strict - Abort on the first value that can't be parsed.
+var strict bool
+
+// maxErrors - Abort after the given number of values that can't be parsed, 0 means no limit.
+var maxErrors int
+
+// sheet, cellRange - Worksheet and cell range to read from xlsx files.
+var sheet, cellRange string
+
+// where - Only analyse the records that match the filter expression.
+var where *csvutil.Expression
+
+// computed - Virtual columns computed from expressions.
+var computed []csvutil.ComputedColumn
+
+// join - Join the files side by side on key columns instead of concatenating them.
+var join *csvutil.Join
+
+// statOptions - Percentiles and quantile method of the statistics.
+var statOptions = stat.DefaultOptions()
+
+// out - Writes the results as tables in the --output format.
+var out = output.NewWriter(os.Stdout, output.Text)
+
+// formatter - Output format of the statistics.
+var formatter stat.Formatter = stat.Text{W: os.Stdout}
+
+// outliers - Outlier detection settings, nil unless requested.
+var outliers *stat.OutlierOptions
+
+// excludeOutliers - Exclude the outliers from the statistics and regressions instead of only reporting them.
+var excludeOutliers bool
+
+// outlierRows - Values flagged as outliers, written as a table at the end in machine readable formats.
+var outlierRows = output.Table{Type: "outliers", Columns: []string{"file", "line", "column", "value", "method", "action"}}
+
+// fits, plots - Regression solutions and plot files, written as tables at the end in machine readable formats.
+var fits []regression.Fit
+var plots = output.Table{Type: "plots", Columns: []string{"title", "file"}}
+
+// distribution - Plots of the distribution of the --column data, nil unless requested.
+var distribution *distributionPlots
+
+// distributionPlots - Settings of the --histogram, --kde and --cdf plots.
+type distributionPlots struct {
+	histogram, kde, cdf bool
+	// Number of histogram bins, 0 chooses them with the rule.
+	bins int
+	rule stat.BinRule
+	// Bandwidth of the kernel density estimate, 0 uses Silverman's rule.
+	bandwidth float64
+	// Title of the plots and label of their X axis.
+	title, label string
+}
+
+// numberParser, numberParsers - Parse functions for all columns and by column, nil uses strconv.ParseFloat.
+var numberParser func(string) (float64, error)
+var numberParsers = map[string]func(string) (float64, error){}
+
+// newCSVFiles - Returns a `*csvutil.CSVFiles` for the given files with the usage options applied.
+func newCSVFiles(files ...string) *csvutil.CSVFiles {
+	cf := csvutil.New(files...)
+	cf.NoHeader = noHeader
+	cf.FilterZero = filterZero
+	cf.Dialect = dialect
+	cf.Missing = missing
+	cf.Headers = headers
+	cf.Strict = strict
+	cf.MaxErrors = maxErrors
+	cf.Sheet = sheet
+	cf.CellRange = cellRange
+	cf.Parser = numberParser
+	cf.Where = where
+	cf.Computed = computed
+	cf.Join = join
+	cf.Parsers = map[string]func(string) (float64, error){}
+	for column, parser := range numberParsers {
+		cf.Parsers[column] = parser
+	}
+	return cf
+}
+
+// parseNumberFormats - Sets up the number parsers from the given specs.
+// A spec is either `<locale>`, used for all the columns, or `<column>=<locale>`.
+// The `raw` locale uses strconv.ParseFloat.
+func parseNumberFormats(specs []string) error {
+	for _, spec := range specs {
+		column, locale := "", spec
+		if i := strings.LastIndex(spec, "="); i >= 0 {
+			column, locale = spec[:i], spec[i+1:]
+		}
+		var parser func(string) (float64, error)
+		if locale != "raw" {
+			f, err := csvutil.NewNumberFormat(locale)
+			if err != nil {
+				return err
+			}
+			parser = f.ParseFloat
+		} else {
+			parser = func(s string) (float64, error) { return strconv.ParseFloat(s, 64) }
+		}
+		if column == "" {
+			numberParser = parser
+		} else {
+			numberParsers[column] = parser
+		}
+	}
+	return nil
+}
+
+// parseJoin - Returns the join settings for the given options.
+// The as-of tolerance accepts units, for example '5s', and is given in seconds for timestamps.
+func parseJoin(joinType string, keys []string, asOf bool, tolerance string) (*csvutil.Join, error) {
+	t, err := csvutil.ParseJoinType(joinType)
+	if err != nil {
+		return nil, err
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("requires at least one --on key column")
+	}
+	j := &csvutil.Join{Type: t, Keys: keys, AsOf: asOf}
+	if asOf {
+		f, _ := csvutil.NewNumberFormat("en")
+		j.Tolerance, err = f.ParseFloat(tolerance)
+		if err != nil {
+			return nil, fmt.Errorf("as-of tolerance: %s", err)
+		}
+	}
+	return j, nil
+}
+
+// printReport - prints the parse errors and warnings of the given csv files to STDERR.
+func printReport(cf *csvutil.CSVFiles) {
+	cf.Report.Print(os.Stderr)
+	if n := cf.Report.Count(); n > 0 {
+		fmt.Fprintf(os.Stderr, "WARNING: %d values could not be parsed\n", n)
+	}
+	cf.Report.Reset()
+}
+
+// stdinArg - Stands for a bare "-", STDIN, while parsing the options, go-getoptions takes a lonesome dash for an option.
+const stdinArg = "\x00stdin"
+
+// hideStdin - Returns the args with each bare "-" before "--" replaced by stdinArg, so it is kept in the remaining arguments.
+func hideStdin(args []string) []string {
+	hidden := append([]string{}, args...)
+	for i, arg := range hidden {
+		if arg == "--" {
+			break
+		}
+		if arg == csvutil.Stdin {
+			hidden[i] = stdinArg
+		}
+	}
+	return hidden
+}
+
+// restoreStdin - Replaces stdinArg with "-" in the remaining arguments, see hideStdin.
+func restoreStdin(remaining []string) []string {
+	for i, arg := range remaining {
+		if arg == stdinArg {
+			remaining[i] = csvutil.Stdin
+		}
+	}
+	return remaining
+}
+
+// parseRune - Parses a single character option, `\t` and `tab` are accepted for the tab character.
+func parseRune(s string) (rune, error) {
+	switch s {
+	case "":
+		return 0, nil
+	case `\t`, "tab":
+		return '\t', nil
+	}
+	r := []rune(s)
+	if len(r) != 1 {
+		return 0, fmt.Errorf("'%s' must be a single character", s)
+	}
+	return r[0], nil
+}
+
 // printError - prints the given error to STDERR.
 func printError(err error) {
 	if err != nil {
@@ -42,27 +236,384 @@ func printError(err error) {
 	}
 }
 
+// printStats - Prints the statistics of the data with the formatter.
+func printStats(data []float64) error {
+	s, err := stat.Describe(data, statOptions)
+	if err != nil {
+		return err
+	}
+	return formatter.WriteSummary(s)
+}
+
+// textOutput - Whether the output is human readable text.
+func textOutput() bool {
+	return out.Format() == output.Text
+}
+
+// printText - Prints informational lines, only in text output.
+func printText(format string, a ...interface{}) {
+	if textOutput() {
+		fmt.Printf(format, a...)
+	}
+}
+
+// printPlot - Prints the name of the plot file, or collects it for the plots table.
+func printPlot(title, name string, err error) {
+	if err != nil {
+		printError(err)
+		return
+	}
+	if textOutput() {
+		fmt.Printf("Plot: %s\n", name)
+		return
+	}
+	plots.Rows = append(plots.Rows, []interface{}{title, name})
+}
+
+// printFit - Prints the regression solution, or collects it for the fits table.
+func printFit(f regression.Fit) {
+	if textOutput() {
+		fmt.Printf("%s\n", f)
+		return
+	}
+	fits = append(fits, f)
+}
+
+// writeResults - Writes the collected fits and plots tables, in machine readable formats.
+func writeResults() error {
+	if textOutput() {
+		return nil
+	}
+	if len(fits) > 0 {
+		err := out.Write(regression.FitsTable(fits))
+		if err != nil {
+			return err
+		}
+	}
+	if len(plots.Rows) > 0 {
+		err := out.Write(plots)
+		if err != nil {
+			return err
+		}
+	}
+	if len(outlierRows.Rows) > 0 {
+		return out.Write(outlierRows)
+	}
+	return nil
+}
+
+// flagOutliers - Detects the outliers of each column and prints them, or collects them for the outliers table.
+// Returns, for each row, whether any of its values is an outlier.
+// The names and positions identify the columns and rows in the output.
+func flagOutliers(columns [][]float64, names []string, positions []csvutil.Position) ([]bool, error) {
+	flagged := make([]bool, len(positions))
+	if outliers == nil {
+		return flagged, nil
+	}
+	action, done := "report", "reported"
+	if excludeOutliers {
+		action, done = "exclude", "excluded"
+	}
+	count := 0
+	for j, column := range columns {
+		indexes, err := stat.Outliers(column, *outliers)
+		if err != nil {
+			return nil, err
+		}
+		for _, i := range indexes {
+			if !flagged[i] {
+				count++
+			}
+			flagged[i] = true
+			printText("Outlier: %s: %s = %g\n", positions[i], names[j], column[i])
+			if !textOutput() {
+				outlierRows.Rows = append(outlierRows.Rows, []interface{}{positions[i].File, positions[i].Line, names[j], column[i], outliers.String(), action})
+			}
+		}
+	}
+	printText("Outliers (%s): %d of %d rows, %s\n", outliers, count, len(positions), done)
+	return flagged, nil
+}
+
+// filterOutliers - Detects the outliers of the columns after the first skip ones, like X, within the rows left after trimming trimStart and trimEnd rows.
+// When excludeOutliers is set, it returns the columns without the rows with an outlier, otherwise the columns as given.
+// Trimming the result by the same number of rows leaves the same first and last rows out.
+func filterOutliers(columns [][]float64, skip int, names []string, positions []csvutil.Position, trimStart, trimEnd int) ([][]float64, error) {
+	n := len(positions)
+	if outliers == nil || trimStart < 0 || trimEnd < 0 || trimStart+trimEnd >= n {
+		return columns, nil
+	}
+	window := make([][]float64, len(columns)-skip)
+	for i := range window {
+		window[i] = columns[skip+i][trimStart : n-trimEnd]
+	}
+	flagged, err := flagOutliers(window, names, positions[trimStart:n-trimEnd])
+	if err != nil || !excludeOutliers {
+		return columns, err
+	}
+	result := make([][]float64, len(columns))
+	for j, column := range columns {
+		for i, x := range column {
+			if i >= trimStart && i < n-trimEnd && flagged[i-trimStart] {
+				continue
+			}
+			result[j] = append(result[j], x)
+		}
+	}
+	return result, nil
+}
+
+// plotFit - Plots the regression solution with the given plot function and prints its fit and plot file.
+func plotFit(f regression.Fit, p regression.Plotter, plot func(regression.Plotter) (string, error)) {
+	var err error
+	f.Plot, err = plot(p)
+	printFit(f)
+	title := p.Name()
+	if f.Kind == "linear" {
+		title = "Linear " + title
+	}
+	printPlot(title, f.Plot, err)
+}
+
+// describeTable - Returns the column summaries as a "describe" output table.
+func describeTable(summaries []*csvutil.ColumnSummary) output.Table {
+	t := output.Table{
+		Type:    "describe",
+		Columns: []string{"column", "name", "type", "layout", "count", "nulls", "unparsable", "distinct", "min", "max", "samples"},
+	}
+	for _, s := range summaries {
+		t.Rows = append(t.Rows, []interface{}{s.Index, s.Name, s.Type.String(), s.Layout, s.Count, s.Nulls, s.Unparsable,
+			s.Distinct, s.Min, s.Max, strings.Join(s.Samples, ", ")})
+	}
+	return t
+}
+
+// plotDistribution - Plots the histogram of the data, with its kernel density estimate, and its empirical CDF, as requested, with a marker per percentile.
+func plotDistribution(data []float64) error {
+	if distribution == nil || len(data) == 0 {
+		return nil
+	}
+	values, err := stat.Percentiles(data, statOptions.Percentiles, statOptions.Method)
+	if err != nil {
+		return err
+	}
+	var markers []regression.Marker
+	for i, p := range statOptions.Percentiles {
+		name := stat.Percentile{P: p, Value: values[i]}.Name()
+		markers = append(markers, regression.Marker{X: values[i], Label: fmt.Sprintf("%s %g", name, values[i])})
+	}
+	if distribution.histogram || distribution.kde {
+		bins := distribution.bins
+		if bins == 0 {
+			bins = stat.Bins(data, distribution.rule)
+		}
+		printText("Histogram: %d bins\n", bins)
+		var density func(float64) float64
+		if distribution.kde {
+			bandwidth := distribution.bandwidth
+			if bandwidth == 0 {
+				bandwidth = stat.SilvermanBandwidth(data)
+			}
+			if math.IsNaN(bandwidth) {
+				fmt.Fprintf(os.Stderr, "WARNING: kde: no spread in the data, skipping the density\n")
+			} else {
+				printText("KDE bandwidth: %f\n", bandwidth)
+				density = stat.KDE(data, bandwidth)
+			}
+		}
+		title := distribution.title + " Histogram"
+		name, err := regression.PlotHistogram(data, bins, density, markers, regression.PlotSettings{
+			Title:  title,
+			XLabel: distribution.label,
+			YLabel: "Density",
+		})
+		printPlot(title, name, err)
+	}
+	if distribution.cdf {
+		title := distribution.title + " CDF"
+		name, err := regression.PlotECDF(data, markers, regression.PlotSettings{
+			Title:  title,
+			XLabel: distribution.label,
+			YLabel: "Proportion",
+		})
+		printPlot(title, name, err)
+	}
+	return nil
+}
+
 // printCSVColumnStats - Given a column and a set of csv files, it will print the statistical information for that column.
-func printCSVColumnStats(files []string, column int) error {
-	var fieldSliceDataset []float64
+// The column can be given as a 1-based index or as a header name.
+// Joined files are read together.
+// With perFile, the statistics of each file and of all of them are printed side by side in a table.
+// With compare, the statistics of each file are printed side by side in a table followed by their comparison to the first file, see stat.Compare.
+// The distribution plots, if any, are of the data of all the files.
+func printCSVColumnStats(files []string, column string, perFile, compare bool) error {
+	var values []float64
+	var positions []csvutil.Position
+	var names []string
+	var counts []int
 
-	for _, file := range files {
-		cf := csvutil.New(file)
-		cf.NoHeader = noHeader
-		cf.FilterZero = filterZero
-		fs, err := cf.GetFloat64Columns(column)
+	// The same *csvutil.CSVFiles checks the headers of every file against the first one.
+	cf := newCSVFiles(files...)
+	for _, source := range fileSources(files) {
+		cf.Files = source
+		fs, ps, err := cf.GetFloat64ColumnsWithPositions(column)
 		if err != nil {
 			return err
 		}
-		l := len(fs[0])
+		printReport(cf)
+		names = append(names, strings.Join(source, "+"))
+		counts = append(counts, len(fs[0]))
+		values = append(values, fs[0]...)
+		positions = append(positions, ps...)
+	}
+	// Outliers are detected in the data of all the files together.
+	flagged, err := flagOutliers([][]float64{values}, []string{column}, positions)
+	if err != nil {
+		return err
+	}
+
+	var fieldSliceDataset []float64
+	var datasets [][]float64
+	var rows []stat.Row
+	start := 0
+	for i, name := range names {
+		var data []float64
+		for j := start; j < start+counts[i]; j++ {
+			if !excludeOutliers || !flagged[j] {
+				data = append(data, values[j])
+			}
+		}
+		start += counts[i]
+		rows = append(rows, stat.NewRow([]string{name}, data, statOptions))
+		datasets = append(datasets, data)
+		l := len(data)
 		if l == 0 {
 			continue
 		}
-		fmt.Printf("Data: %d columns, %v\n", len(fs[0]), fs[0])
-		fieldSliceDataset = append(fieldSliceDataset, fs[0]...)
+		if !perFile && !compare {
+			printText("Data: %d columns, %v\n", len(data), data)
+		}
+		fieldSliceDataset = append(fieldSliceDataset, data...)
 	}
-	stat.PrintSliceStats(fieldSliceDataset)
-	return nil
+	switch {
+	case compare:
+		err = printComparison([]string{"Source"}, rows, names, datasets)
+		if err != nil {
+			return fmt.Errorf("column '%s': %s", column, err)
+		}
+	case perFile:
+		rows = append(rows, stat.NewRow([]string{"all"}, fieldSliceDataset, statOptions))
+		err = formatter.WriteTable([]string{"Source"}, rows)
+		if err != nil {
+			return err
+		}
+	default:
+		err = printStats(fieldSliceDataset)
+		if err != nil {
+			return fmt.Errorf("column '%s': %s", column, err)
+		}
+	}
+	err = plotDistribution(fieldSliceDataset)
+	if err != nil {
+		return fmt.Errorf("column '%s': %s", column, err)
+	}
+	return writeResults()
+}
+
+// printCSVGroupStats - Given key columns, a column and a set of csv files, it will print the statistical information for that column for each distinct combination of values of the key columns, as a table.
+// The groups are in order of first appearance unless sortBy, any statistic of stat.Summary.Names, is given.
+// With top, only the first top groups are printed.
+// With compare, the table is followed by the comparison of the groups to the first one, see stat.Compare.
+func printCSVGroupStats(files []string, keys []string, column, sortBy string, descending bool, top int, compare bool) error {
+	cf := newCSVFiles(files...)
+	groups, err := cf.GroupFloat64ColumnByName(keys, column)
+	if err != nil {
+		return err
+	}
+	printReport(cf)
+	rows := make([]stat.Row, len(groups))
+	values := map[string][]float64{}
+	for i, g := range groups {
+		rows[i] = stat.NewRow(g.Keys, g.Values, statOptions)
+		values[strings.Join(g.Keys, ", ")] = g.Values
+	}
+	if sortBy != "" {
+		err = stat.SortRows(rows, sortBy, descending)
+		if err != nil {
+			return err
+		}
+	}
+	if top > 0 && top < len(rows) {
+		rows = rows[:top]
+	}
+	if compare {
+		names := make([]string, len(rows))
+		datasets := make([][]float64, len(rows))
+		for i, row := range rows {
+			names[i] = strings.Join(row.Keys, ", ")
+			datasets[i] = values[names[i]]
+		}
+		return printComparison(keys, rows, names, datasets)
+	}
+	return formatter.WriteTable(keys, rows)
+}
+
+// printComparison - Prints the rows as a table followed by the comparison of the datasets to the first one.
+func printComparison(keys []string, rows []stat.Row, names []string, datasets [][]float64) error {
+	c, err := stat.Compare(names, datasets, statOptions)
+	if err != nil {
+		return err
+	}
+	err = formatter.WriteTable(keys, rows)
+	if err != nil {
+		return err
+	}
+	printText("\n")
+	return formatter.WriteComparison(c)
+}
+
+// printCorrelation - Given columns and a set of csv files, it will print the correlation and covariance matrices of the columns and their pairs ranked by the coefficient of the method.
+// Rows are aligned with the --missing policy, so all the coefficients use the same rows.
+// With top, only the first top pairs are printed.
+// With heatmap, the matrix of the method is plotted with the plot settings.
+func printCorrelation(files []string, columns []string, method stat.CorrelationMethod, top int, heatmap bool, ps regression.PlotSettings) error {
+	cf := newCSVFiles(files...)
+	data, err := cf.GetFloat64ColumnsByName(columns...)
+	if err != nil {
+		return err
+	}
+	printReport(cf)
+	m, err := stat.Correlations(columns, data)
+	if err != nil {
+		return err
+	}
+	pairs := m.Pairs(method)
+	if top > 0 && top < len(pairs) {
+		pairs = pairs[:top]
+	}
+	err = formatter.WriteCorrelation(m, method, pairs)
+	if err != nil {
+		return err
+	}
+	if heatmap {
+		printText("\n")
+		name, err := regression.PlotHeatMap(columns, m.Matrix(method), -1, 1, ps)
+		printPlot(ps.Title, name, err)
+	}
+	return writeResults()
+}
+
+// fileSources - Returns the files grouped in the sources that are read together, one per file unless they are joined.
+func fileSources(files []string) [][]string {
+	if join != nil {
+		return [][]string{files}
+	}
+	sources := make([][]string, len(files))
+	for i, file := range files {
+		sources[i] = []string{file}
+	}
+	return sources
 }
 
 func validateMinInt(min, value int) error {
@@ -105,35 +656,301 @@ func trimSlice(x []float64, trimStart, trimEnd int) ([]float64, error) {
 }
 
 func synopsis() {
-	synopsis := `csv-analysis --column|-c <n> <csv-file>...
-       [--no-header|--nh] [--filter-zero|--fz] 
+	synopsis := `csv-analysis --column|-c <n|name> <csv-file>...
+       [--no-header|--nh] [--filter-zero|--fz] [--missing <policy>]
+       [--headers <policy>]
+       [--strict] [--max-errors <n>] [--per-file]
+       [--histogram [--bins <rule|n>]] [--kde [--bandwidth <x>]] [--cdf]
+       [--plot-title <title>] [--plot-x-label <label>]
+
+# Compare files, or groups with --group-by
+csv-analysis --column|-c <n|name> --compare <csv-file> <csv-file>...
+
+# Group by
+csv-analysis --column|-c <n|name> --group-by <n|name>... <csv-file>...
+       [--sort <statistic> [--desc]] [--top <n>] [--compare]
+
+# Correlation
+csv-analysis --correlation <n|name>... <csv-file>...
+       [--correlation-method <method>] [--top <n>] [--heatmap]
+       [--plot-title <title>]
 
 # Regression analysis
-csv-analysis -x <n> -y <n> <csv-file>...
-       [--no-header|--nh] [--filter-zero|--fz]
+csv-analysis -x <n|name> -y <n|name>... <csv-file>...
+       [--no-header|--nh] [--filter-zero|--fz] [--missing <policy>]
+       [--headers <policy>]
+       [--strict] [--max-errors <n>]
 			 [--trim-start|--ts <n>] [--trim-end|--te <n>]
 			 [--degree] [--regression] [--review]
 			 [--plot-title <title>] [--plot-x-label <label>] [--plot-y-label <label>]
 
 # Time plot
-csv-analysis -x <n> -y <n> <csv-file>... -xtime <timeformat>
-       [--no-header|--nh] [--filter-zero|--fz]
+csv-analysis -x <n|name> -y <n|name>... <csv-file>... -xtime <timeformat>
+       [--no-header|--nh] [--filter-zero|--fz] [--missing <policy>]
+       [--headers <policy>]
+       [--strict] [--max-errors <n>]
 			 [--trim-start|--ts <n>] [--trim-end|--te <n>]
 			 [--plot-title <title>] [--plot-x-label <label>] [--plot-y-label <label>]
-			 [--bold]
+			 [--bold] [--per-file]
 
 # Inspect data and exit
 csv-analysis [--show-header|-s] [--show-data|--sd] <csv-file>...
+csv-analysis --describe <csv-file>...
+
+# CSV format options, valid for all the modes above
+       [--delimiter|-d <char>] [--comment <char>] [--skip-lines <n>]
+       [--lazy-quotes] [--trim-leading-space]
+       [--number <locale>|<n|name>=<locale>]...
+       [--where <expression>] [--compute <name>=<expression>]...
+
+# Statistics options, valid for all the modes above
+       [--percentiles <p,p...>] [--quantile-method <method>]
+       [--mean-trim <percent>] [--mode-bins <n>]
+       [--confidence <percent>] [--bootstrap <statistic>]...
+       [--resamples <n>] [--seed <n>]
+
+# Outlier options, valid for --column, regression and time plots
+       [--outliers <method> [--outlier-threshold <x>] [--outlier-max <n>]
+        [--exclude-outliers]]
+
+# Output options, valid for all the modes above
+       [--output|-o <text|json|csv|tsv|markdown>]
+
+# Join options, valid for all the modes above
+       [--join <inner|left|outer> --on <n|name>... [--asof [<tolerance>]]]
+
+# xlsx options, valid for all the modes above
+       [--sheet <name|n>] [--range <A1:D100>]
 
 csv-analysis [--help]
 
+# <csv-file>: One or more csv files, use '-' to read from STDIN.
+#             gzip, bzip2, xz and zstd compressed files are detected and
+#             decompressed transparently.
+#             Excel xlsx workbooks are read as csv data.
+#             Newline delimited JSON is read as csv data, use the dotted
+#             field path as column name, for example 'metrics.latency.p99'.
+#
 # --column: Column to use for statistical analysis. n starts at 1.
+#           Columns can also be given by header name, case-insensitive name
+#           or glob, for example 'latency_*'. The name must match a single
+#           column in every file.
+#           The 'source' virtual column has the name of the file each record
+#           comes from, for example: --where 'source =~ "^run-2"'
+#
+# --per-file: Show the statistics of each file, and of all of them, side by
+#             side in a table. In time plots, plot each file as its own
+#             series, labeled by file name.
+#
+# --histogram: Plot the histogram of the column as a PNG file, normalized to
+#              a density, with a dashed line per percentile.
+#              The plot title and X label default to the column name.
+#
+# --bins: Number of histogram bins, or the rule to choose it: sturges
+#         (default), ceil(log2(n)) + 1; scott, bins of width
+#         3.49 * sd * n^(-1/3); or fd, Freedman-Diaconis, bins of width
+#         2 * IQR * n^(-1/3), robust to outliers and skewed data.
+#
+# --kde: Draw the gaussian kernel density estimate over the histogram,
+#        implies --histogram.
+#
+# --bandwidth: Bandwidth of the kernel density estimate. Default: Silverman's
+#              rule of thumb, 0.9 * min(sd, IQR / 1.34) * n^(-1/5).
+#
+# --cdf: Plot the empirical cumulative distribution function of the column
+#        as a PNG file, with a dashed line per percentile.
+#
+# --compare: Show the statistics of each file side by side in a table and
+#            compare each file to the first one, the baseline: the
+#            difference of the mean, median and percentiles, Welch's t-test,
+#            Mann-Whitney U and Kolmogorov-Smirnov tests, with p-values and
+#            effect sizes. With more than two files, also one-way ANOVA and
+#            Kruskal-Wallis tests of all of them.
+#            With --group-by, compare the groups to the first one instead.
+#            Example: --column latency --compare before.csv after.csv
+#
+# --group-by: Show the statistics of the column for each distinct value of
+#             the key column, as a table. Can be repeated to group by the
+#             combination of values. Use 'source' to group by file.
+#             Example:
+#             --column latency --group-by host --sort mean --desc --top 10
+#
+# --sort: Sort the groups by a statistic: count, min, max, mean, sd,
+#         median, mad, sum, iqr, a percentile, like p99, or a shape
+#         statistic: cv, skewness, kurtosis, mode, geometric_mean,
+#         harmonic_mean, trimmed_mean or winsorized_mean.
+#         Statistics not in the text table are added as its last column.
+#         Ascending unless --desc is given.
+#         By default, groups are in order of first appearance.
+#
+# --correlation: Show the Pearson, Spearman and Kendall correlation and the
+#                covariance matrices of two or more columns, and the pairs of
+#                columns ranked by strength: strong from 0.7, moderate from
+#                0.4, weak from 0.2 and none below.
+#                Records with a missing value in any of the columns follow
+#                the --missing policy.
+#                Example: csv-analysis export.csv --correlation cpu memory latency
+#
+# --correlation-method: Coefficient used to rank the pairs and for the
+#                       heatmap: pearson (default), spearman or kendall.
+#
+# --heatmap: Plot the correlation matrix as a heatmap PNG file.
+#
+# --top: Only show the first n groups, or the first n pairs with
+#        --correlation.
+#
+# --percentiles: Comma separated list of percentiles to report, between 0
+#                and 100. Default: 50,90,95,99,99.9
+#
+# --quantile-method: How percentiles and quartiles between two data points
+#                    are calculated, like numpy.quantile methods:
+#                    linear (default, R-7, Excel PERCENTILE.INC), lower,
+#                    higher, nearest, midpoint, inverted-cdf (R-1, nearest
+#                    rank), hazen (R-5), weibull (R-6, Excel
+#                    PERCENTILE.EXC) or median-unbiased (R-8).
+#
+# --mean-trim: Percent of the values left out, or replaced, at each end for
+#              the trimmed and winsorized means, less than 50. Default: 10
+#
+# --mode-bins: Number of histogram bins used to find the mode. By default,
+#              the mode is the most frequent value when the data has at most
+#              sqrt(n) distinct values and otherwise the center of the
+#              fullest of ceil(log2(n)) + 1 bins, Sturges' rule.
+#
+# --confidence: Report confidence intervals at the given level, for
+#               example 95: t-based for the mean and from order statistics,
+#               data points, for the median and the percentiles. Bounds that
+#               need more data, like for p99.9 of a small sample, are not
+#               reported.
+#
+# --bootstrap: Report the percentile bootstrap confidence interval of the
+#              statistic, like sd or p99. Can be repeated. 95% unless
+#              --confidence is given.
+#
+# --resamples: Number of bootstrap resamples. Default: 1000
+#
+# --seed: Seed of the bootstrap resampling, the same seed gives the same
+#         intervals. Default: 1
+#
+# --outliers: Detect outliers and report them with their file and line:
+#             iqr, outside Tukey's fences, Q1 - k * IQR and Q3 + k * IQR;
+#             zscore, |x - mean| / σ above the threshold; modified-zscore,
+#             0.6745 * |x - median| / MAD above the threshold; grubbs,
+#             repeated Grubbs' test; or esd, generalized ESD test.
+#             grubbs and esd assume normally distributed data.
+#             Outliers are looked for in --column, or in each -y column,
+#             after --trim-start and --trim-end.
+#
+# --outlier-threshold: k for iqr (default 1.5), the score for zscore
+#                      (default 3) and modified-zscore (default 3.5) or the
+#                      significance level for grubbs and esd (default 0.05).
+#
+# --outlier-max: Maximum number of outliers for esd.
+#                Default: 10% of the data.
+#
+# --exclude-outliers: Leave the records with outliers out of the statistics,
+#                     plots and regressions.
+#
+# --output: Output format: text (default), json, csv, tsv or markdown.
+#           The machine readable formats write the results as tables and
+#           leave out the informational lines. In json, each table is an
+#           object on its own line with its type and rows:
+#           {"type":"summary","rows":[{"count":3,"min":10,...}]}
+#           In csv, tsv and markdown, each table has a header row and
+#           tables are separated by an empty line.
+#           Tables: summary, stats (--per-file, --compare and --group-by),
+#           differences and tests (--compare), correlation (method, column
+#           and one column per correlated column) and pairs (--correlation),
+#           fits (regression solutions with coefficients, r2, sd and plot
+#           file), plots (title and file), outliers (file, line, column,
+#           value, method and action) and describe.
 #
 # --no-header: The csv file has no header.
 #              It is assumed that it does by default.
 #
 # --filter-zero: Ignore zeroes from statistical analysis.
 #
+# --missing: How to handle records where any of the requested columns is
+#            empty, unparsable or filtered out, so X and Y stay aligned:
+#            drop (default), previous or interpolate.
+#
+# --headers: How to handle files with a header that differs from the header
+#            of the first file: warn (default), with the missing, extra and
+#            moved columns; fail; align, to realign the columns by name to
+#            the header of the first file; or ignore.
+#
+# --strict: Abort on the first value that can't be parsed.
+#
+# --max-errors: Abort after n values that can't be parsed.
+#
+# --delimiter: Field delimiter, use '\t' or 'tab' for TSV files.
+#              By default it is detected from the first rows of each file.
+#
+# --comment: Ignore lines starting with the given character.
+#
+# --skip-lines: Skip n preamble lines before the CSV data starts.
+#
+# --lazy-quotes: Allow quotes in unquoted fields and non-doubled quotes in
+#                quoted fields.
+#
+# --trim-leading-space: Ignore leading white space in fields.
+#
+# --number: Parse numbers written for humans with the given locale, like
+#           'en' or 'de_DE', for all columns or for the given column.
+#           Can be repeated. Thousands separators are checked, currency
+#           symbols and percent signs are stripped, and unit suffixes are
+#           normalised: durations to seconds, 'm' is minutes, bytes with SI
+#           or IEC prefixes to bytes and bare SI prefixes, k, M, G...
+#           Use 'raw' to parse plain numbers only.
+#           Examples:
+#           --number en --number price=de
+#           --number latency=en  # 250ms => 0.25
+#
+# --where: Only analyse the records that match the expression.
+#          Columns are referenced by name, by index, $3, or by name
+#          between backquotes when it has spaces or symbols.
+#          Operators: or, ||, and, &&, not, !, ==, !=, <, <=, >, >=,
+#          =~ and !~ for regex matches, in lo..hi for inclusive ranges and
+#          in (a, b) for lists. Use quotes for strings.
+#          Values are compared as numbers when both sides are numbers.
+#          Examples:
+#          --where 'status == "200" and region == "eu"'
+#          --where 'latency in 100..500 and path =~ "^/api/"'
+#
+# --compute: Add a virtual column with the result of an arithmetic
+#            expression over other columns. Can be repeated, later
+#            columns can use earlier ones. It can be used with --column,
+#            -x, -y and --where like any other column.
+#            Operators: +, -, *, /, % and ^. Functions: abs, ceil, floor,
+#            round, sqrt, exp, log, log2, log10, pow(x, y), min(a, b...),
+#            max(a, b...) and time(x[, layout]), timestamp to Unix seconds.
+#            Results that are not a number are empty, missing values.
+#            Examples:
+#            --compute 'throughput=bytes / duration' -c throughput
+#            --compute 'elapsed=time(end_ts) - time(start_ts)'
+#
+# --join: Join the csv files side by side on the --on key columns instead of
+#         concatenating them: inner, only records with a match in every
+#         file; left, every record of the first file; or outer, every
+#         record of every file. The joined records have the columns of the
+#         first file followed by the columns of the other files, except
+#         their keys. Repeated names get the file number as suffix,
+#         'latency_2' for the second file.
+#
+# --on: Key column, can be repeated. Resolved in the header of each file.
+#
+# --asof: Match the last key to the record with the nearest value, within
+#         the optional tolerance, instead of an exact match. Keys are
+#         numbers or timestamps, in seconds. For example '5s' or '1m'.
+#         Example:
+#         load.csv latency.csv --join left --on host --on time --asof 5s
+#
+# --sheet: xlsx worksheet to read, by name or index starting at 1.
+#          Names take precedence. The first sheet by default.
+#
+# --range: xlsx cell range to read, for example 'A1:D100' or 'B:D'.
+#          The first row in the range is the header.
+#
 # --x, --y: columns to use for X and Y when doing regression analysis.
 #
 # --trim-start, --trim-end: Trim fields from the CSV dataset.
@@ -146,6 +963,12 @@ csv-analysis [--help]
 #
 # --show-data: Show the header and the first row of the first csv file and exit.
 #
+# --describe: Scan the csv files and show, for every column, the inferred
+#             type, null and unparsable counts, distinct count, min, max and
+#             sample values, and exit.
+#             Types: integer, float, timestamp with its layout, boolean,
+#             categorical, text or empty.
+#
 # --debug: Show debug output.
 #
 # --xtime: Mon Jan 2 15:04:05.000 MST 2006
@@ -157,28 +980,102 @@ csv-analysis [--help]
 }
 
 func main() {
-	var column, xColumn int // field to analize
+	var column, xColumn string // field to analize
 	var trimStart, trimEnd, degree int
 	var pTitle, pYLabel, pXLabel string
 	var xTimeFormat string
 	var review, bold bool
+	var delimiter, comment string
+	var missingPolicy, headerPolicy string
+	var perFile bool
+	var compare bool
+	var correlationMethod string
+	var histogram, kde, cdf bool
+	var bins string
+	var bandwidth float64
+	var heatmap bool
+	var sortBy string
+	var descending bool
+	var top int
+	var percentiles, quantileMethod string
+	var meanTrim float64
+	var confidence float64
+	var seed int
+	var outlierMethod string
+	var outlierOptions stat.OutlierOptions
+	var outputFormat string
 
 	opt := getoptions.New()
 	// General options
 	opt.Bool("help", false)
 	opt.Bool("debug", false)
+	opt.StringVar(&outputFormat, "output", "text", "o")
 	// CSV review options
 	opt.Bool("show-data", false, "sd")
 	opt.Bool("show-header", false, "s")
+	opt.Bool("describe", false)
 	// CSV parsing options
 	opt.BoolVar(&noHeader, "no-header", false, "nh")
 	opt.BoolVar(&filterZero, "filter-zero", false, "fz")
+	opt.StringVar(&missingPolicy, "missing", "drop")
+	opt.StringVar(&headerPolicy, "headers", "warn")
+	opt.BoolVar(&strict, "strict", false)
+	opt.IntVar(&maxErrors, "max-errors", 0)
 	opt.BoolVar(&review, "review", false)
+	opt.BoolVar(&perFile, "per-file", false)
+	opt.BoolVar(&compare, "compare", false)
+	// Group by options
+	groupBy := opt.StringSlice("group-by", 1, 1)
+	opt.StringVar(&sortBy, "sort", "")
+	opt.BoolVar(&descending, "desc", false)
+	opt.IntVar(&top, "top", 0)
+	// Correlation options
+	correlationColumns := opt.StringSlice("correlation", 1, 99)
+	opt.StringVar(&correlationMethod, "correlation-method", "pearson")
+	opt.BoolVar(&heatmap, "heatmap", false)
+	// Distribution plot options
+	opt.BoolVar(&histogram, "histogram", false)
+	opt.StringVar(&bins, "bins", "sturges")
+	opt.BoolVar(&kde, "kde", false)
+	opt.Float64Var(&bandwidth, "bandwidth", 0)
+	opt.BoolVar(&cdf, "cdf", false)
+	// Statistics options
+	opt.StringVar(&percentiles, "percentiles", "50,90,95,99,99.9")
+	opt.StringVar(&quantileMethod, "quantile-method", "linear")
+	opt.Float64Var(&meanTrim, "mean-trim", stat.DefaultTrim*100)
+	opt.IntVar(&statOptions.ModeBins, "mode-bins", 0)
+	opt.Float64Var(&confidence, "confidence", 95)
+	bootstrap := opt.StringSlice("bootstrap", 1, 1)
+	opt.IntVar(&statOptions.Resamples, "resamples", stat.DefaultResamples)
+	opt.IntVar(&seed, "seed", 1)
+	// Outlier options
+	opt.StringVar(&outlierMethod, "outliers", "")
+	opt.Float64Var(&outlierOptions.Threshold, "outlier-threshold", 0)
+	opt.IntVar(&outlierOptions.Max, "outlier-max", 0)
+	opt.BoolVar(&excludeOutliers, "exclude-outliers", false)
+	// CSV dialect options
+	opt.StringVar(&delimiter, "delimiter", "", "d")
+	opt.StringVar(&comment, "comment", "")
+	opt.IntVar(&dialect.SkipLines, "skip-lines", 0)
+	opt.BoolVar(&dialect.LazyQuotes, "lazy-quotes", false)
+	opt.BoolVar(&dialect.TrimLeadingSpace, "trim-leading-space", false)
+	numberSpecs := opt.StringSlice("number", 1, 1)
+	var whereExpression string
+	opt.StringVar(&whereExpression, "where", "")
+	computeSpecs := opt.StringSlice("compute", 1, 1)
+	// Join options
+	var joinType, asOfTolerance string
+	opt.StringVar(&joinType, "join", "")
+	joinKeys := opt.StringSlice("on", 1, 1)
+	opt.StringVarOptional(&asOfTolerance, "asof", "0")
+	// xlsx options
+	opt.StringVar(&sheet, "sheet", "")
+	opt.StringVar(&cellRange, "range", "")
 	// CSV data indicators
-	opt.IntVar(&column, "column", 1, "c")
-	opt.IntVar(&xColumn, "x", 1)
+	opt.StringVar(&column, "column", "1", "c")
+	opt.StringVar(&xColumn, "x", "1")
 	opt.StringVarOptional(&xTimeFormat, "xtime", time.RFC3339)
-	yColumns := opt.IntSlice("y", 1, 99)
+	yColumns := opt.StringSlice("y", 1, 99)
 	// CSV data trimming
 	opt.IntVar(&trimStart, "trim-start", 0, "ts")
 	opt.IntVar(&trimEnd, "trim-end", 0, "te")
@@ -191,11 +1088,12 @@ func main() {
 	opt.StringVar(&pXLabel, "plot-x-label", "", "px")
 	opt.StringVar(&pYLabel, "plot-y-label", "", "py")
 	opt.BoolVar(&bold, "bold", false)
-	remaining, err := opt.Parse(os.Args[1:])
+	remaining, err := opt.Parse(hideStdin(os.Args[1:]))
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "ERROR: %s\n", err)
 		os.Exit(1)
 	}
+	remaining = restoreStdin(remaining)
 	if opt.Called("help") {
 		synopsis()
 		os.Exit(1)
@@ -206,6 +1104,143 @@ func main() {
 		log.SetOutput(ioutil.Discard)
 	}
 	log.Println(remaining)
+	dialect.Delimiter, err = parseRune(delimiter)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: delimiter %s\n", err)
+		os.Exit(1)
+	}
+	dialect.Comment, err = parseRune(comment)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: comment %s\n", err)
+		os.Exit(1)
+	}
+	missing, err = csvutil.ParseMissingPolicy(missingPolicy)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: %s\n", err)
+		os.Exit(1)
+	}
+	// The statistics, fits and plots don't accept NaN values.
+	if missing == csvutil.FillNaN {
+		fmt.Fprintf(os.Stderr, "ERROR: missing value policy 'nan' is not supported, use drop, previous or interpolate\n")
+		os.Exit(1)
+	}
+	headers, err = csvutil.ParseHeaderPolicy(headerPolicy)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: %s\n", err)
+		os.Exit(1)
+	}
+	format, err := output.ParseFormat(outputFormat)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: %s\n", err)
+		os.Exit(1)
+	}
+	if format != output.Text {
+		out = output.NewWriter(os.Stdout, format)
+		formatter = stat.Tables{Out: out}
+	} else if sortBy != "" {
+		// Show the statistic the table is sorted by.
+		formatter = stat.Text{W: os.Stdout, Extra: []string{sortBy}}
+	}
+	statOptions.Percentiles, err = stat.ParsePercentiles(percentiles)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: %s\n", err)
+		os.Exit(1)
+	}
+	statOptions.Method, err = stat.ParseQuantileMethod(quantileMethod)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: %s\n", err)
+		os.Exit(1)
+	}
+	if meanTrim < 0 || meanTrim >= 50 {
+		fmt.Fprintf(os.Stderr, "ERROR: mean-trim must be between 0 and 50\n")
+		os.Exit(1)
+	}
+	statOptions.Trim = meanTrim / 100
+	err = validateMinInt(0, statOptions.ModeBins)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: mode-bins %s\n", err)
+		os.Exit(1)
+	}
+	if opt.Called("confidence") || len(*bootstrap) > 0 {
+		if confidence <= 0 || confidence >= 100 {
+			fmt.Fprintf(os.Stderr, "ERROR: confidence must be between 0 and 100\n")
+			os.Exit(1)
+		}
+		statOptions.Level = confidence / 100
+		statOptions.Bootstrap = *bootstrap
+		statOptions.Seed = int64(seed)
+	}
+	err = validateMinInt(1, statOptions.Resamples)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: resamples %s\n", err)
+		os.Exit(1)
+	}
+	if opt.Called("outliers") {
+		outlierOptions.Method, err = stat.ParseOutlierMethod(outlierMethod)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "ERROR: %s\n", err)
+			os.Exit(1)
+		}
+		outlierOptions.Quantile = statOptions.Method
+		outliers = &outlierOptions
+	} else if excludeOutliers {
+		fmt.Fprintf(os.Stderr, "ERROR: exclude-outliers requires --outliers\n")
+		os.Exit(1)
+	}
+	if histogram || kde || cdf {
+		distribution = &distributionPlots{histogram: histogram, kde: kde, cdf: cdf, bandwidth: bandwidth, title: pTitle, label: pXLabel}
+		if n, err := strconv.Atoi(bins); err == nil {
+			err = validateMinInt(1, n)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "ERROR: bins %s\n", err)
+				os.Exit(1)
+			}
+			distribution.bins = n
+		} else {
+			distribution.rule, err = stat.ParseBinRule(bins)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "ERROR: %s\n", err)
+				os.Exit(1)
+			}
+		}
+		if bandwidth < 0 {
+			fmt.Fprintf(os.Stderr, "ERROR: bandwidth can not be negative\n")
+			os.Exit(1)
+		}
+		if !opt.Called("plot-title") {
+			distribution.title = column
+		}
+		if !opt.Called("plot-x-label") {
+			distribution.label = column
+		}
+	}
+	err = parseNumberFormats(*numberSpecs)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: number %s\n", err)
+		os.Exit(1)
+	}
+	if opt.Called("where") {
+		where, err = csvutil.ParseExpression(whereExpression)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "ERROR: where %s\n", err)
+			os.Exit(1)
+		}
+	}
+	for _, spec := range *computeSpecs {
+		c, err := csvutil.ParseComputedColumn(spec)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "ERROR: %s\n", err)
+			os.Exit(1)
+		}
+		computed = append(computed, c)
+	}
+	if opt.Called("join") {
+		join, err = parseJoin(joinType, *joinKeys, opt.Called("asof"), asOfTolerance)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "ERROR: join %s\n", err)
+			os.Exit(1)
+		}
+	}
 	if len(remaining) < 1 {
 		fmt.Fprintf(os.Stderr, "ERROR: Missing file\n")
 		os.Exit(1)
@@ -214,9 +1249,7 @@ func main() {
 	// Inspect data and quit
 	if opt.Called("show-header") || opt.Called("show-data") {
 		var err error
-		cf := csvutil.New(remaining[0])
-		cf.NoHeader = noHeader
-		cf.FilterZero = filterZero
+		cf := newCSVFiles(remaining[0])
 		if opt.Called("show-data") {
 			err = cf.PrintCSVRows(1, 2)
 		} else {
@@ -228,40 +1261,127 @@ func main() {
 		}
 		os.Exit(1)
 	}
-	if opt.Called("x") && opt.Called("y") && opt.Called("xtime") {
-		cf := csvutil.New(remaining...)
-		cf.NoHeader = noHeader
-		cf.FilterZero = filterZero
-		sliceDatasetsString, err := cf.GetCSVColumns(xColumn)
+	if opt.Called("describe") {
+		cf := newCSVFiles(remaining...)
+		summaries, err := cf.Describe()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "ERROR: %s\n", err)
+			os.Exit(1)
+		}
+		if textOutput() {
+			csvutil.PrintColumnSummaries(os.Stdout, summaries)
+			return
+		}
+		printError(out.Write(describeTable(summaries)))
+		return
+	}
+	if opt.Called("correlation") {
+		method, err := stat.ParseCorrelationMethod(correlationMethod)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "ERROR: %s\n", err)
+			os.Exit(1)
+		}
+		err = validateMinInt(0, top)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "ERROR: top %s\n", err)
+			os.Exit(1)
+		}
+		title := pTitle
+		if !opt.Called("plot-title") {
+			title = method.String() + " correlation"
+		}
+		ps := regression.PlotSettings{Title: title, XLabel: pXLabel, YLabel: pYLabel}
+		err = printCorrelation(remaining, *correlationColumns, method, top, heatmap, ps)
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "ERROR: %s\n", err)
 			os.Exit(1)
 		}
-		var xSliceDataset []float64
-		for _, e := range sliceDatasetsString[0] {
-			trimmed := strings.TrimSpace(e)
-			trimmedXTimeFormat := strings.TrimSpace(xTimeFormat)
-			t, err := time.Parse(trimmedXTimeFormat, trimmed)
+		os.Exit(0)
+	}
+	if opt.Called("x") && opt.Called("y") && opt.Called("xtime") {
+		trimmedXTimeFormat := strings.TrimSpace(xTimeFormat)
+		xTimeParser := func(s string) (float64, error) {
+			t, err := time.Parse(trimmedXTimeFormat, s)
 			if err != nil {
-				fmt.Fprintf(os.Stderr, "ERROR: time format '%s': %s\n", xTimeFormat, err)
-				continue
+				return 0, fmt.Errorf("time format '%s': %s", xTimeFormat, err)
 			}
-			xSliceDataset = append(xSliceDataset, float64(t.Unix()))
+			return float64(t.Unix()), nil
 		}
-		xTrimmed, err := trimSlice(xSliceDataset, trimStart, trimEnd)
+		query := []string{xColumn}
+		query = append(query, (*yColumns)...)
+		ps := regression.PlotSettings{
+			Title:  pTitle,
+			XLabel: pXLabel,
+			YLabel: pYLabel,
+			Bold:   bold,
+		}
+		if perFile {
+			// One series per file and Y column, labeled by file.
+			var series []regression.Series
+			var rows []stat.Row
+			var all []float64
+			cf := newCSVFiles(remaining...)
+			cf.Parsers[xColumn] = xTimeParser
+			for _, source := range fileSources(remaining) {
+				cf.Files = source
+				sliceDatasets, positions, err := cf.GetFloat64ColumnsWithPositions(query...)
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "ERROR: %s\n", err)
+					os.Exit(1)
+				}
+				printReport(cf)
+				sliceDatasets, err = filterOutliers(sliceDatasets, 1, *yColumns, positions, trimStart, trimEnd)
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "ERROR: %s\n", err)
+					os.Exit(1)
+				}
+				name := strings.Join(source, "+")
+				xTrimmed, err := trimSlice(sliceDatasets[0], trimStart, trimEnd)
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "ERROR: %s: %s\n", name, err)
+					continue
+				}
+				for i, ySliceDataset := range sliceDatasets[1:] {
+					yTrimmed, _ := trimSlice(ySliceDataset, trimStart, trimEnd)
+					label := name
+					if len(*yColumns) > 1 {
+						label = fmt.Sprintf("%s %s", name, (*yColumns)[i])
+					}
+					series = append(series, regression.Series{X: xTrimmed, Y: yTrimmed, Label: label})
+				}
+				printText("%s Count: %d, Trim Start: %d, Trim End: %d\n", name, len(xTrimmed), trimStart, trimEnd)
+				rows = append(rows, stat.NewRow([]string{name}, sliceDatasets[1], statOptions))
+				all = append(all, sliceDatasets[1]...)
+			}
+			name, err := regression.PlotTimeSeries(series, ps)
+			printPlot(ps.Title, name, err)
+			rows = append(rows, stat.NewRow([]string{"all"}, all, statOptions))
+			err = formatter.WriteTable([]string{"Source"}, rows)
+			printError(err)
+			printError(writeResults())
+			return
+		}
+		cf := newCSVFiles(remaining...)
+		cf.Parsers[xColumn] = xTimeParser
+		sliceDatasets, positions, err := cf.GetFloat64ColumnsWithPositions(query...)
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "ERROR: %s\n", err)
 			os.Exit(1)
 		}
-
-		sliceDatasets, err := cf.GetFloat64Columns(*yColumns...)
-		// TODO: Add error to check for different column lenghts
+		printReport(cf)
+		sliceDatasets, err = filterOutliers(sliceDatasets, 1, *yColumns, positions, trimStart, trimEnd)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "ERROR: %s\n", err)
+			os.Exit(1)
+		}
+		xTrimmed, err := trimSlice(sliceDatasets[0], trimStart, trimEnd)
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "ERROR: %s\n", err)
 			os.Exit(1)
 		}
+
 		var sYTrimmed [][]float64
-		for i, ySliceDataset := range sliceDatasets {
+		for i, ySliceDataset := range sliceDatasets[1:] {
 			yTrimmed, _ := trimSlice(ySliceDataset, trimStart, trimEnd)
 			if len(yTrimmed) < 1 {
 				fmt.Fprintf(os.Stderr, "ERROR: column '%d' is empty. Removing it!\n", i)
@@ -273,26 +1393,25 @@ func main() {
 		// TODO: maybe show this only with verbose option
 		// fmt.Printf("Column X (%d): %v\n", xColumn, xTrimmed)
 		// fmt.Printf("Column Y (%v): %v\n", *yColumns, sYTrimmed)
-		fmt.Printf("Count: %d, Trim Start: %d, Trim End: %d\n", len(xTrimmed), trimStart, trimEnd)
+		printText("Count: %d, Trim Start: %d, Trim End: %d\n", len(xTrimmed), trimStart, trimEnd)
 
-		regression.PlotTimeData(xTrimmed, sYTrimmed, regression.PlotSettings{
-			Title:  pTitle,
-			XLabel: pXLabel,
-			YLabel: pYLabel,
-			Bold:   bold,
-		})
-		err = printCSVColumnStats(remaining, (*yColumns)[0])
+		name, err := regression.PlotTimeDataFile(xTrimmed, sYTrimmed, ps)
+		printPlot(ps.Title, name, err)
+		// Use the data already read, STDIN can't be read twice.
+		err = printStats(sliceDatasets[1])
+		printError(err)
+		printError(writeResults())
+	} else if opt.Called("x") && opt.Called("y") {
+		cf := newCSVFiles(remaining...)
+		query := []string{xColumn}
+		query = append(query, (*yColumns)...)
+		sliceDatasets, positions, err := cf.GetFloat64ColumnsWithPositions(query...)
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "ERROR: %s\n", err)
 			os.Exit(1)
 		}
-	} else if opt.Called("x") && opt.Called("y") {
-		cf := csvutil.New(remaining...)
-		cf.NoHeader = noHeader
-		cf.FilterZero = filterZero
-		query := []int{xColumn}
-		query = append(query, (*yColumns)...)
-		sliceDatasets, err := cf.GetFloat64Columns(query...)
+		printReport(cf)
+		sliceDatasets, err = filterOutliers(sliceDatasets, 1, *yColumns, positions, trimStart, trimEnd)
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "ERROR: %s\n", err)
 			os.Exit(1)
@@ -309,11 +1428,11 @@ func main() {
 		}
 
 		// TODO: maybe show this only with verbose option
-		fmt.Printf("Column X (%d): %v\n", xColumn, xTrimmed)
-		fmt.Printf("Column Y (%v): %v\n", *yColumns, sYTrimmed)
-		fmt.Printf("Count: %d, Trim Start: %d, Trim End: %d\n", len(xTrimmed), trimStart, trimEnd)
+		printText("Column X (%s): %v\n", xColumn, xTrimmed)
+		printText("Column Y (%v): %v\n", *yColumns, sYTrimmed)
+		printText("Count: %d, Trim Start: %d, Trim End: %d\n", len(xTrimmed), trimStart, trimEnd)
 
-		regression.PlotRegression(xTrimmed, sYTrimmed, func(x float64) float64 { return x }, 0, 0, regression.PlotSettings{
+		name, err := regression.PlotRegressionFile(xTrimmed, sYTrimmed, func(x float64) float64 { return x }, 0, 0, regression.PlotSettings{
 			Title:     pTitle,
 			XLabel:    pXLabel,
 			YLabel:    pYLabel,
@@ -323,15 +1442,16 @@ func main() {
 			fmt.Fprintf(os.Stderr, "ERROR: %s\n", err)
 			os.Exit(1)
 		}
+		printPlot(pTitle, name, nil)
 		if !opt.Called("regression") {
+			printError(writeResults())
 			os.Exit(0)
 		}
 
 		// Original data
 		solution, err := regression.SolveTransformation(xTrimmed, sYTrimmed[0], &regression.None{})
 		if err == nil {
-			err = solution.Plot(&regression.None{})
-			printError(err)
+			plotFit(solution.Fit(&regression.None{}), &regression.None{}, solution.PlotFile)
 		} else {
 			printError(err)
 		}
@@ -357,12 +1477,11 @@ func main() {
 			solution, err = regression.SolveTransformation(
 				xTrimmed, sYTrimmed[0], lt.(regression.LinearTransformation))
 			if err == nil {
+				p := lt.(regression.Plotter)
 				if review {
-					err = solution.PlotLinearTransformation(lt.(regression.Plotter))
-					printError(err)
+					plotFit(solution.LinearFit(p), p, solution.PlotLinearTransformationFile)
 				}
-				err = solution.Plot(lt.(regression.Plotter))
-				printError(err)
+				plotFit(solution.Fit(p), p, solution.PlotFile)
 			} else {
 				printError(err)
 			}
@@ -382,10 +1501,27 @@ func main() {
 		// }
 		// log.Printf("S (matrix):\n%3.3g\n", mat.Formatted(si.A, mat.Prefix(""), mat.Squeeze()))
 
-		s.Plot()
+		f := s.Fit()
+		f.Plot, err = s.PlotFile()
+		printFit(f)
+		printPlot("Polynomial Regression", f.Plot, err)
+		printError(writeResults())
+	} else if opt.Called("group-by") {
+		// Get column stats by group
+		err := validateMinInt(0, top)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "ERROR: top %s\n", err)
+			os.Exit(1)
+		}
+		err = printCSVGroupStats(remaining, *groupBy, column, sortBy, descending, top, compare)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "ERROR: %s\n", err)
+			os.Exit(1)
+		}
+		os.Exit(0)
 	} else {
 		// Get column stats
-		err := printCSVColumnStats(remaining, column)
+		err := printCSVColumnStats(remaining, column, perFile, compare)
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "ERROR: %s\n", err)
 			os.Exit(1)
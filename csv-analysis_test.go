@@ -1 +1,66 @@
+// This file is part of csv-analysis.
+//
+// Copyright (C) 2017  David Gamba Rios
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
 package main
+
+import (
+	"os"
+	"os/exec"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestMain - Runs the CLI instead of the tests when CSV_ANALYSIS_MAIN is set, so tests can run it as a subprocess.
+func TestMain(m *testing.M) {
+	if os.Getenv("CSV_ANALYSIS_MAIN") != "" {
+		main()
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+func TestHideStdin(t *testing.T) {
+	args := []string{"a.csv", "-", "-c", "lat", "--", "-"}
+	hidden := hideStdin(args)
+	expected := []string{"a.csv", stdinArg, "-c", "lat", "--", "-"}
+	if !reflect.DeepEqual(hidden, expected) {
+		t.Errorf("Wrong args: %q != %q\n", hidden, expected)
+	}
+	remaining := restoreStdin([]string{"a.csv", stdinArg})
+	if !reflect.DeepEqual(remaining, []string{"a.csv", "-"}) {
+		t.Errorf("Wrong remaining: %q\n", remaining)
+	}
+}
+
+// runCLI - Runs the CLI with the given STDIN and arguments, returns its combined output.
+func runCLI(stdin string, args ...string) (string, error) {
+	cmd := exec.Command(os.Args[0], args...)
+	cmd.Env = append(os.Environ(), "CSV_ANALYSIS_MAIN=1")
+	cmd.Stdin = strings.NewReader(stdin)
+	out, err := cmd.CombinedOutput()
+	return string(out), err
+}
+
+func TestStdin(t *testing.T) {
+	for _, args := range [][]string{{"-c", "lat", "-"}, {"-", "-c", "lat"}} {
+		out, err := runCLI("lat\n1\n2\n3\n", args...)
+		if err != nil {
+			t.Fatalf("Unexpected error for %q: %s\n%s\n", args, err, out)
+		}
+		if !strings.Contains(out, "Count: 3\n") || !strings.Contains(out, "Mean: 2.000000\n") {
+			t.Errorf("Wrong output for %q:\n%s\n", args, out)
+		}
+	}
+}
+
+func TestMissingNaN(t *testing.T) {
+	out, err := runCLI("y\n1\n\n4\n", "-c", "y", "--missing", "nan", "-")
+	if err == nil || out != "ERROR: missing value policy 'nan' is not supported, use drop, previous or interpolate\n" {
+		t.Errorf("Unexpected result: %v, %s\n", err, out)
+	}
+}
@@ -0,0 +1,87 @@
+// This file is part of csv-analysis.
+//
+// Copyright (C) 2017  David Gamba Rios
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package csvutil
+
+import (
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// columnSpecs - Converts a list of 1-based column indexes into column specs.
+func columnSpecs(columns []int) []string {
+	specs := make([]string, len(columns))
+	for i, c := range columns {
+		specs[i] = strconv.Itoa(c)
+	}
+	return specs
+}
+
+// resolveColumns - Given a header row, returns the 1-based indexes for the given column specs.
+func resolveColumns(header []string, specs ...string) ([]int, error) {
+	indexes := make([]int, len(specs))
+	for i, spec := range specs {
+		index, err := resolveColumn(header, spec)
+		if err != nil {
+			return nil, err
+		}
+		indexes[i] = index
+	}
+	return indexes, nil
+}
+
+// resolveColumn - Given a header row, returns the 1-based index for the given column spec.
+//
+// The spec is resolved in the following order:
+//
+//   - An integer is used as the 1-based column index.
+//   - An exact header name match.
+//   - A case-insensitive header name match.
+//   - A case-insensitive glob match, for example "latency_*".
+//
+// The first rule with matches wins, and it must match a single column.
+func resolveColumn(header []string, spec string) (int, error) {
+	if index, err := strconv.Atoi(strings.TrimSpace(spec)); err == nil {
+		return index, nil
+	}
+	if header == nil {
+		return 0, fmt.Errorf("Column name error: '%s' requires a header row!", spec)
+	}
+	matchers := []func(name string) bool{
+		func(name string) bool { return name == spec },
+		func(name string) bool { return strings.EqualFold(name, spec) },
+		func(name string) bool {
+			ok, _ := path.Match(strings.ToLower(spec), strings.ToLower(name))
+			return ok
+		},
+	}
+	if _, err := path.Match(spec, ""); err != nil {
+		return 0, fmt.Errorf("Column name error: '%s' %s!", spec, err)
+	}
+	for _, match := range matchers {
+		var found []int
+		for i, name := range header {
+			if match(strings.TrimSpace(name)) {
+				found = append(found, i+1)
+			}
+		}
+		if len(found) == 1 {
+			return found[0], nil
+		}
+		if len(found) > 1 {
+			var names []string
+			for _, i := range found {
+				names = append(names, fmt.Sprintf("%d:%s", i, header[i-1]))
+			}
+			return 0, fmt.Errorf("Column name error: '%s' is ambiguous, matches %s!", spec, strings.Join(names, ", "))
+		}
+	}
+	return 0, fmt.Errorf("Column name error: '%s' not found in header!", spec)
+}
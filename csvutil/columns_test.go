@@ -0,0 +1,51 @@
+// This file is part of csv-analysis.
+//
+// Copyright (C) 2017  David Gamba Rios
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+package csvutil
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestResolveColumns(t *testing.T) {
+	header := []string{"Time", "latency_ms", "Status", "status", "bytes_in", "bytes_out"}
+	tests := []struct {
+		specs    []string
+		expected []int
+		err      string
+	}{
+		{[]string{"1", "3"}, []int{1, 3}, ""},
+		{[]string{"Time"}, []int{1}, ""},
+		{[]string{"time"}, []int{1}, ""},
+		{[]string{"status", "Status"}, []int{4, 3}, ""},
+		{[]string{"STATUS"}, nil, "Column name error: 'STATUS' is ambiguous, matches 3:Status, 4:status!"},
+		{[]string{"latency_*"}, []int{2}, ""},
+		{[]string{"bytes_*"}, nil, "Column name error: 'bytes_*' is ambiguous, matches 5:bytes_in, 6:bytes_out!"},
+		{[]string{"size"}, nil, "Column name error: 'size' not found in header!"},
+		{[]string{"[a"}, nil, "Column name error: '[a' syntax error in pattern!"},
+	}
+	for _, test := range tests {
+		indexes, err := resolveColumns(header, test.specs...)
+		if test.err != "" {
+			if err == nil || err.Error() != test.err {
+				t.Errorf("Unexpected error for %v: %v != %s\n", test.specs, err, test.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("Unexpected error: %s\n", err)
+		}
+		if !reflect.DeepEqual(indexes, test.expected) {
+			t.Errorf("Wrong indexes: %v != %v\n", indexes, test.expected)
+		}
+	}
+	_, err := resolveColumns(nil, "Time")
+	if err == nil || err.Error() != "Column name error: 'Time' requires a header row!" {
+		t.Errorf("Unexpected error: %v\n", err)
+	}
+}
@@ -0,0 +1,78 @@
+// This file is part of csv-analysis.
+//
+// Copyright (C) 2017  David Gamba Rios
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package csvutil
+
+import (
+	"bufio"
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"io"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/ulikunitz/xz"
+)
+
+// Magic bytes at the start of compressed streams.
+var (
+	gzipMagic = []byte{0x1f, 0x8b}
+	// Followed by the block size, '1' to '9'.
+	bzip2Magic = []byte("BZh")
+	xzMagic    = []byte{0xfd, '7', 'z', 'X', 'Z', 0x00}
+	zstdMagic  = []byte{0x28, 0xb5, 0x2f, 0xfd}
+)
+
+// readCloser - Reader that closes every layer of a decompressed file.
+type readCloser struct {
+	io.Reader
+	closers []io.Closer
+}
+
+// Close - Closes the decompressor and the underlying file, returning the first error.
+func (rc *readCloser) Close() error {
+	var err error
+	for _, c := range rc.closers {
+		if e := c.Close(); e != nil && err == nil {
+			err = e
+		}
+	}
+	return err
+}
+
+// decompress - Returns a reader that transparently decompresses `rc`.
+// The compression format is detected from the magic bytes at the start of the stream: gzip, bzip2, xz and zstd are supported.
+// Uncompressed streams are returned as they are.
+func decompress(rc io.ReadCloser) (io.ReadCloser, error) {
+	br := bufio.NewReader(rc)
+	magic := peek(br, len(xzMagic))
+	switch {
+	case bytes.HasPrefix(magic, gzipMagic):
+		r, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, err
+		}
+		return &readCloser{Reader: r, closers: []io.Closer{r, rc}}, nil
+	case bytes.HasPrefix(magic, bzip2Magic) && len(magic) > 3 && magic[3] >= '1' && magic[3] <= '9':
+		return &readCloser{Reader: bzip2.NewReader(br), closers: []io.Closer{rc}}, nil
+	case bytes.HasPrefix(magic, xzMagic):
+		r, err := xz.NewReader(br)
+		if err != nil {
+			return nil, err
+		}
+		return &readCloser{Reader: r, closers: []io.Closer{rc}}, nil
+	case bytes.HasPrefix(magic, zstdMagic):
+		r, err := zstd.NewReader(br)
+		if err != nil {
+			return nil, err
+		}
+		d := r.IOReadCloser()
+		return &readCloser{Reader: d, closers: []io.Closer{d, rc}}, nil
+	}
+	return &readCloser{Reader: br, closers: []io.Closer{rc}}, nil
+}
@@ -0,0 +1,71 @@
+// This file is part of csv-analysis.
+//
+// Copyright (C) 2017  David Gamba Rios
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+package csvutil
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/ulikunitz/xz"
+)
+
+func TestDecompress(t *testing.T) {
+	in := "a,b\n1,2\n"
+	compress := func(w io.WriteCloser, err error, buf *bytes.Buffer) []byte {
+		if err != nil {
+			t.Fatalf("Unexpected error: %s\n", err)
+		}
+		w.Write([]byte(in))
+		w.Close()
+		return buf.Bytes()
+	}
+	var gzBuf, xzBuf, zstdBuf bytes.Buffer
+	gzw := gzip.NewWriter(&gzBuf)
+	xzw, xzErr := xz.NewWriter(&xzBuf)
+	zstdw, zstdErr := zstd.NewWriter(&zstdBuf)
+	tests := map[string][]byte{
+		"plain": []byte(in),
+		"gzip":  compress(gzw, nil, &gzBuf),
+		"xz":    compress(xzw, xzErr, &xzBuf),
+		"zstd":  compress(zstdw, zstdErr, &zstdBuf),
+		// bzip2 -c
+		"bzip2": []byte{0x42, 0x5a, 0x68, 0x39, 0x31, 0x41, 0x59, 0x26, 0x53, 0x59, 0xbf, 0x87, 0x40, 0x7f, 0x00, 0x00, 0x03, 0x59, 0x00, 0x00, 0x10, 0x00, 0x04, 0x30, 0x00, 0x30, 0x00, 0x20, 0x00, 0x30, 0xc0, 0x08, 0x69, 0xb2, 0x88, 0x23, 0x27, 0x8b, 0xb9, 0x22, 0x9c, 0x28, 0x48, 0x5f, 0xc3, 0xa0, 0x3f, 0x80},
+	}
+	for name, data := range tests {
+		rc, err := decompress(ioutil.NopCloser(bytes.NewReader(data)))
+		if err != nil {
+			t.Fatalf("Unexpected error for %s: %s\n", name, err)
+		}
+		out, err := ioutil.ReadAll(rc)
+		if err != nil {
+			t.Fatalf("Unexpected error for %s: %s\n", name, err)
+		}
+		rc.Close()
+		if string(out) != in {
+			t.Errorf("Wrong data for %s: %q != %q\n", name, out, in)
+		}
+	}
+	// Plain data that starts like bzip2, without the block size.
+	plain := "BZh_id,value\n1,2\n"
+	rc, err := decompress(ioutil.NopCloser(bytes.NewReader([]byte(plain))))
+	if err != nil {
+		t.Fatalf("Unexpected error: %s\n", err)
+	}
+	out, _ := ioutil.ReadAll(rc)
+	if string(out) != plain {
+		t.Errorf("Wrong data: %q != %q\n", out, plain)
+	}
+	_, err = decompress(ioutil.NopCloser(bytes.NewReader([]byte{0x1f, 0x8b, 0x00})))
+	if err == nil {
+		t.Errorf("Expected error not thrown\n")
+	}
+}
@@ -8,15 +8,15 @@
 
 /*
 Package csvutil provides ways to extract csv data from one or more files.
+
+Excel xlsx workbooks are read as csv data, one worksheet at a time.
+Newline delimited JSON is read as csv data with the dotted field paths of the objects as header, for example "metrics.latency.p99".
 */
 package csvutil
 
 import (
-	"encoding/csv"
 	"fmt"
 	"io"
-	"os"
-	"strconv"
 	"strings"
 )
 
@@ -27,6 +27,58 @@ type CSVFiles struct {
 	NoHeader bool
 	// Indicates if the 0 value should be filtered.
 	FilterZero bool
+	// CSV format settings.
+	Dialect Dialect
+	// Policy for records with missing values when extracting aligned floats.
+	Missing MissingPolicy
+	// Optional parse functions keyed by column, given as 1-based indexes or header names and resolved against the header of each file, see resolveColumn.
+	// Columns without a parse function use Parser or strconv.ParseFloat.
+	Parsers map[string]func(string) (float64, error)
+	// Optional parse function for the columns without one in Parsers, see NumberFormat.
+	Parser func(string) (float64, error)
+	// Parse errors and warnings found while reading the files.
+	Report Report
+	// Indicates if reading should abort on the first parse error.
+	Strict bool
+	// Maximum number of parse errors before reading aborts, 0 means no limit.
+	MaxErrors int
+	// Worksheet to read from xlsx files, by name or 1-based index, names take precedence. The first sheet when empty.
+	Sheet string
+	// Cell range to read from xlsx files, like "A1:D100" or "B:D". The whole sheet when empty.
+	CellRange string
+	// Optional filter, only the records where the expression is true are read, see ParseExpression.
+	Where *Expression
+	// Virtual columns appended, in order, after the columns of each file.
+	// They are used like any other column and the Where filter can reference them.
+	Computed []ComputedColumn
+	// Optional settings to join the files side by side instead of concatenating them.
+	Join *Join
+	// How to handle files with a header that differs from the header of the first file read.
+	Headers HeaderPolicy
+
+	// Header of the first file read and its name, the headers of the other files are compared to it.
+	firstHeader []string
+	firstFile   string
+}
+
+// ComputedColumn - Virtual column with the values of an expression, see ParseExpression.
+// Numbers that are NaN or infinite, like the result of arithmetic on empty values, are empty.
+type ComputedColumn struct {
+	Name       string
+	Expression *Expression
+}
+
+// ParseComputedColumn - Parses a computed column given as `<name>=<expression>`, for example "throughput=bytes / duration".
+func ParseComputedColumn(s string) (ComputedColumn, error) {
+	i := strings.Index(s, "=")
+	if i <= 0 {
+		return ComputedColumn{}, fmt.Errorf("computed column '%s' must be given as <name>=<expression>", s)
+	}
+	e, err := ParseExpression(s[i+1:])
+	if err != nil {
+		return ComputedColumn{}, fmt.Errorf("computed column '%s': %s", s[:i], err)
+	}
+	return ComputedColumn{Name: strings.TrimSpace(s[:i]), Expression: e}, nil
 }
 
 // New - Returns a `*csvutil.CSVFiles` with the files given.
@@ -37,49 +89,69 @@ func New(files ...string) *CSVFiles {
 // GetCSVColumns - Reads csv lines from *csvutil.CSVFiles and returns the requested columns.
 // If the column lenghts are different, it will error out (necessary here? maybe the caller should check for that.)
 func (cf *CSVFiles) GetCSVColumns(columns ...int) ([][]string, error) {
+	return cf.GetCSVColumnsByName(columnSpecs(columns)...)
+}
+
+// GetCSVColumnsByName - Reads csv lines from *csvutil.CSVFiles and returns the requested columns.
+// Columns can be given as 1-based indexes or as header names, see resolveColumn.
+// Names are resolved against the header of each file.
+func (cf *CSVFiles) GetCSVColumnsByName(columns ...string) ([][]string, error) {
 	columnsData := make([][]string, len(columns))
-	for _, file := range cf.Files {
-		fh, err := os.Open(file)
-		if err != nil {
-			return columnsData, err
-		}
-		defer fh.Close()
-		fs, err := getCSVColumns(fh, columns...)
-		fh.Close()
+	err := cf.eachReader(columns, func(r *Reader) error {
+		fs, err := readColumns(r)
 		if err != nil {
-			return columnsData, err
+			return err
 		}
 		l := len(fs[0])
 		for i, columnString := range fs {
 			lc := len(columnString)
 			if l == 0 {
-				fmt.Fprintf(os.Stderr, "Column %d is empty, file: %s\n", columns[i], file)
+				cf.addWarning("Column %s is empty, file: %s", columns[i], r.name)
 				continue
 			}
 			if l != lc {
-				fmt.Fprintf(os.Stderr, "WARNING: Column lenghts do not match\n")
-			}
-			if cf.NoHeader {
-				columnsData[i] = append(columnsData[i], columnString...)
-			} else {
-				if len(columnString) >= 1 {
-					columnsData[i] = append(columnsData[i], columnString[1:]...)
-				}
+				cf.addWarning("Column lenghts do not match, file: %s", r.name)
 			}
+			columnsData[i] = append(columnsData[i], columnString...)
+		}
+		return nil
+	})
+	return columnsData, err
+}
+
+// eachReader - Calls `f` with a *csvutil.Reader for each file, for the requested columns.
+// When Join is set, `f` is called once with a Reader over the joined files.
+func (cf *CSVFiles) eachReader(columns []string, f func(r *Reader) error) error {
+	if cf.Join != nil {
+		src, err := cf.join()
+		if err != nil {
+			return err
 		}
+		return f(&Reader{cf: cf, src: src, name: strings.Join(cf.Files, "+"), columns: columns})
 	}
-	return columnsData, nil
+	for _, file := range cf.Files {
+		fh, err := cf.open(file)
+		if err != nil {
+			return err
+		}
+		err = f(cf.NewReader(fh, file, columns...))
+		fh.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 // PrintCSVRows - prints the given csv rows
 func (cf *CSVFiles) PrintCSVRows(rows ...int) error {
 	for _, file := range cf.Files {
-		fh, err := os.Open(file)
+		fh, err := cf.open(file)
 		if err != nil {
 			return err
 		}
 		defer fh.Close()
-		rowData, err := getCSVRows(fh, rows...)
+		rowData, err := getCSVRows(fh, cf.Dialect, rows...)
 		fh.Close()
 		if err != nil {
 			return err
@@ -100,31 +172,112 @@ func (cf *CSVFiles) PrintCSVRows(rows ...int) error {
 
 // GetFloat64Columns - given a set of CSV files and a list of columns, it will return those columns as a slice of floats.
 // If filterZero is set, it will ignore Zero values.
-// If the column lenghts are different, it will error out (necessary here? maybe the caller should check for that.)
+// The returned columns are aligned, see GetFloat64Rows.
 func (cf *CSVFiles) GetFloat64Columns(columns ...int) ([][]float64, error) {
-	cSlices, err := cf.GetCSVColumns(columns...)
+	return cf.GetFloat64ColumnsByName(columnSpecs(columns)...)
+}
+
+// GetFloat64ColumnsByName - given a set of CSV files and a list of columns, it will return those columns as a slice of floats.
+// Columns can be given as 1-based indexes or as header names, see resolveColumn.
+// The returned columns are aligned, see GetFloat64Rows.
+func (cf *CSVFiles) GetFloat64ColumnsByName(columns ...string) ([][]float64, error) {
+	rows, err := cf.GetFloat64Rows(columns...)
+	if err != nil {
+		return nil, err
+	}
+	return rowsToColumns(rows, len(columns)), nil
+}
+
+// GetFloat64ColumnsWithPositions - Same as GetFloat64ColumnsByName, it also returns the position of each row, see GetFloat64RowsWithPositions.
+func (cf *CSVFiles) GetFloat64ColumnsWithPositions(columns ...string) ([][]float64, []Position, error) {
+	rows, positions, err := cf.GetFloat64RowsWithPositions(columns...)
+	if err != nil {
+		return nil, nil, err
+	}
+	return rowsToColumns(rows, len(columns)), positions, nil
+}
+
+// GetCSVRecords - Reads csv lines from *csvutil.CSVFiles and returns, for each record, the requested columns.
+// Columns can be given as 1-based indexes or as header names, see resolveColumn.
+// Fields missing from short records are returned as empty strings.
+func (cf *CSVFiles) GetCSVRecords(columns ...string) ([][]string, error) {
+	var recordsData [][]string
+	err := cf.eachReader(columns, func(r *Reader) error {
+		for r.Next() {
+			recordsData = append(recordsData, r.Record())
+		}
+		return r.Err()
+	})
 	if err != nil {
 		return nil, err
 	}
-	sliceDatasets := make([][]float64, len(columns))
-	for i, cSlice := range cSlices {
-		for j := range cSlice {
-			trimmed := strings.TrimSpace(cSlice[j])
-			x64, err := strconv.ParseFloat(trimmed, 64)
+	return recordsData, nil
+}
+
+// GetFloat64Rows - given a set of CSV files and a list of columns, it will return, for each record, the requested columns as floats.
+// Columns can be given as 1-based indexes or as header names, see resolveColumn.
+// Values that are empty, unparsable or zero when FilterZero is set are considered missing and the Missing policy is applied to the whole record, so the columns are kept aligned.
+// The policy is applied to each file separately.
+// Unparsable values are added to the Report.
+func (cf *CSVFiles) GetFloat64Rows(columns ...string) ([][]float64, error) {
+	rows, _, err := cf.GetFloat64RowsWithPositions(columns...)
+	return rows, err
+}
+
+// Position - File and 1-based line number where a record starts.
+type Position struct {
+	File string
+	Line int
+}
+
+func (p Position) String() string {
+	return fmt.Sprintf("%s:%d", p.File, p.Line)
+}
+
+// GetFloat64RowsWithPositions - Same as GetFloat64Rows, it also returns the position of each row.
+func (cf *CSVFiles) GetFloat64RowsWithPositions(columns ...string) ([][]float64, []Position, error) {
+	var rowsData [][]float64
+	var positions []Position
+	err := cf.eachReader(columns, func(r *Reader) error {
+		var rows [][]float64
+		for r.Next() {
+			row, err := r.Float64Record()
 			if err != nil {
-				fmt.Fprintf(os.Stderr, "ERROR: %s\n", err)
-			} else {
-				if !cf.FilterZero || (cf.FilterZero && x64 != 0) {
-					sliceDatasets[i] = append(sliceDatasets[i], x64)
-				}
+				return err
 			}
+			// The line goes in an extra column so it follows the rows kept by the missing policy.
+			rows = append(rows, append(row, float64(r.Line())))
+		}
+		if err := r.Err(); err != nil {
+			return err
+		}
+		for _, row := range applyMissingPolicy(rows, cf.Missing) {
+			n := len(row) - 1
+			rowsData = append(rowsData, row[:n])
+			positions = append(positions, Position{File: r.name, Line: int(row[n])})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return rowsData, positions, nil
+}
+
+// rowsToColumns - Transposes rows into n columns.
+func rowsToColumns(rows [][]float64, n int) [][]float64 {
+	columnsData := make([][]float64, n)
+	for _, row := range rows {
+		for i := range columnsData {
+			columnsData[i] = append(columnsData[i], row[i])
 		}
 	}
-	return sliceDatasets, nil
+	return columnsData
 }
 
-// getCSVRows - Reads csv lines from `reader` and returns the requested rows.
-func getCSVRows(reader io.Reader, rows ...int) ([][]string, error) {
+// getCSVRows - Reads csv lines from `reader` using the given dialect and returns the requested rows.
+// The header, if any, is row 1.
+func getCSVRows(reader io.Reader, d Dialect, rows ...int) ([][]string, error) {
 	rowsData := make([][]string, len(rows))
 	// Verify query
 	maxRow := 0
@@ -136,58 +289,46 @@ func getCSVRows(reader io.Reader, rows ...int) ([][]string, error) {
 			maxRow = r
 		}
 	}
-	r := csv.NewReader(reader)
-	r.FieldsPerRecord = -1
+	cf := &CSVFiles{NoHeader: true, Dialect: d}
+	r := cf.NewReader(reader, "")
 	rowCounter := 0
 	for {
 		rowCounter++
 		if rowCounter > maxRow {
 			break
 		}
-		record, err := r.Read()
-		if err == io.EOF {
+		if !r.Next() {
 			break
 		}
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "ERROR: %s\n", err)
-			return nil, err
-		}
 		for i, row := range rows {
 			if rowCounter == row {
-				rowsData[i] = append(rowsData[i], record...)
+				rowsData[i] = append(rowsData[i], r.Raw()...)
 			}
 		}
 	}
-	return rowsData, nil
+	return rowsData, r.Err()
 }
 
-// getCSVColumns - Reads csv lines from `reader` and returns the requested columns.
-func getCSVColumns(reader io.Reader, columns ...int) ([][]string, error) {
-	columnsData := make([][]string, len(columns))
-	// Verify query
-	for _, c := range columns {
-		if c <= 0 {
-			return nil, fmt.Errorf("Column index error: %d <= 0!", c)
-		}
-	}
-	r := csv.NewReader(reader)
-	r.FieldsPerRecord = -1
-	row := 0
-	for {
-		row++
-		record, err := r.Read()
-		if err == io.EOF {
-			break
-		}
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "ERROR: %s\n", err)
-			return nil, err
-		}
-		for i, column := range columns {
-			if len(record) >= column {
-				columnsData[i] = append(columnsData[i], record[column-1])
+// getCSVColumns - Reads csv lines from `reader` using the given dialect and returns the requested columns.
+// The header, if any, is included.
+func getCSVColumns(reader io.Reader, d Dialect, columns ...int) ([][]string, error) {
+	cf := &CSVFiles{NoHeader: true, Dialect: d}
+	return readColumns(cf.NewReader(reader, "", columnSpecs(columns)...))
+}
+
+// readColumns - Reads the records from `r` and returns the requested columns.
+// Short records don't add a value to the columns they are missing.
+func readColumns(r *Reader) ([][]string, error) {
+	columnsData := make([][]string, len(r.columns))
+	for r.Next() {
+		for i, column := range r.Indexes() {
+			if len(r.Raw()) >= column {
+				columnsData[i] = append(columnsData[i], r.Raw()[column-1])
 			}
 		}
 	}
+	if err := r.Err(); err != nil {
+		return nil, err
+	}
 	return columnsData, nil
 }
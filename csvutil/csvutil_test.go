@@ -10,6 +10,7 @@ package csvutil
 import (
 	"io/ioutil"
 	"log"
+	"math"
 	"os"
 	"reflect"
 	"strings"
@@ -23,14 +24,14 @@ func TestGetCSVColumns(t *testing.T) {
 1,2,3
 1,2,3
 `
-	_, err := getCSVColumns(strings.NewReader(in), 0)
+	_, err := getCSVColumns(strings.NewReader(in), Dialect{}, 0)
 	if err == nil {
 		t.Fatalf("Expected error not thrown\n")
 	}
 	if err.Error() != "Column index error: 0 <= 0!" {
 		t.Fatalf("Unexpected error: %s\n", err)
 	}
-	cdata, err := getCSVColumns(strings.NewReader(in), 1)
+	cdata, err := getCSVColumns(strings.NewReader(in), Dialect{}, 1)
 	if err != nil {
 		t.Fatalf("Unexpected error: %s\n", err)
 	}
@@ -38,7 +39,7 @@ func TestGetCSVColumns(t *testing.T) {
 	if !reflect.DeepEqual(cdata, expected) {
 		t.Errorf("Wrong data: %v != %v\n", cdata, expected)
 	}
-	cdata, err = getCSVColumns(strings.NewReader(in), 2)
+	cdata, err = getCSVColumns(strings.NewReader(in), Dialect{}, 2)
 	if err != nil {
 		t.Fatalf("Unexpected error: %s\n", err)
 	}
@@ -46,7 +47,7 @@ func TestGetCSVColumns(t *testing.T) {
 	if !reflect.DeepEqual(cdata, expected) {
 		t.Errorf("Wrong data: %v != %v\n", cdata, expected)
 	}
-	cdata, err = getCSVColumns(strings.NewReader(in), 3)
+	cdata, err = getCSVColumns(strings.NewReader(in), Dialect{}, 3)
 	if err != nil {
 		t.Fatalf("Unexpected error: %s\n", err)
 	}
@@ -54,7 +55,7 @@ func TestGetCSVColumns(t *testing.T) {
 	if !reflect.DeepEqual(cdata, expected) {
 		t.Errorf("Wrong data: %v != %v\n", cdata, expected)
 	}
-	cdata, err = getCSVColumns(strings.NewReader(in), 4)
+	cdata, err = getCSVColumns(strings.NewReader(in), Dialect{}, 4)
 	if err != nil {
 		t.Fatalf("Unexpected error: %s\n", err)
 	}
@@ -70,14 +71,14 @@ func TestGetCSVRows(t *testing.T) {
 1,1,1
 2,2,2
 `
-	_, err := getCSVRows(strings.NewReader(in), 0)
+	_, err := getCSVRows(strings.NewReader(in), Dialect{}, 0)
 	if err == nil {
 		t.Fatalf("Expected error not thrown\n")
 	}
 	if err.Error() != "Row index error: 0 <= 0!" {
 		t.Fatalf("Unexpected error: %s\n", err)
 	}
-	rdata, err := getCSVRows(strings.NewReader(in), 1)
+	rdata, err := getCSVRows(strings.NewReader(in), Dialect{}, 1)
 	if err != nil {
 		t.Fatalf("Unexpected error: %s\n", err)
 	}
@@ -85,7 +86,7 @@ func TestGetCSVRows(t *testing.T) {
 	if !reflect.DeepEqual(rdata, expected) {
 		t.Errorf("Wrong data: %v != %v\n", rdata, expected)
 	}
-	rdata, err = getCSVRows(strings.NewReader(in), 2)
+	rdata, err = getCSVRows(strings.NewReader(in), Dialect{}, 2)
 	if err != nil {
 		t.Fatalf("Unexpected error: %s\n", err)
 	}
@@ -93,7 +94,7 @@ func TestGetCSVRows(t *testing.T) {
 	if !reflect.DeepEqual(rdata, expected) {
 		t.Errorf("Wrong data: %v != %v\n", rdata, expected)
 	}
-	rdata, err = getCSVRows(strings.NewReader(in), 3)
+	rdata, err = getCSVRows(strings.NewReader(in), Dialect{}, 3)
 	if err != nil {
 		t.Fatalf("Unexpected error: %s\n", err)
 	}
@@ -101,7 +102,7 @@ func TestGetCSVRows(t *testing.T) {
 	if !reflect.DeepEqual(rdata, expected) {
 		t.Errorf("Wrong data: %v != %v\n", rdata, expected)
 	}
-	rdata, err = getCSVRows(strings.NewReader(in), 4)
+	rdata, err = getCSVRows(strings.NewReader(in), Dialect{}, 4)
 	if err != nil {
 		t.Fatalf("Unexpected error: %s\n", err)
 	}
@@ -110,3 +111,152 @@ func TestGetCSVRows(t *testing.T) {
 		t.Errorf("Wrong data: %v != %v\n", rdata, expected)
 	}
 }
+
+func TestReader(t *testing.T) {
+	in := `a,b,c
+1,2,3
+4
+7,8,9
+`
+	cf := New()
+	r := cf.NewReader(strings.NewReader(in), "in", "c", "1")
+	var rdata [][]string
+	var lines []int
+	for r.Next() {
+		rdata = append(rdata, r.Record())
+		lines = append(lines, r.Line())
+	}
+	if err := r.Err(); err != nil {
+		t.Fatalf("Unexpected error: %s\n", err)
+	}
+	expected := [][]string{[]string{"3", "1"}, []string{"", "4"}, []string{"9", "7"}}
+	if !reflect.DeepEqual(rdata, expected) {
+		t.Errorf("Wrong data: %v != %v\n", rdata, expected)
+	}
+	expectedLines := []int{2, 3, 4}
+	if !reflect.DeepEqual(lines, expectedLines) {
+		t.Errorf("Wrong lines: %v != %v\n", lines, expectedLines)
+	}
+	expectedHeader := []string{"a", "b", "c"}
+	if !reflect.DeepEqual(r.Header(), expectedHeader) {
+		t.Errorf("Wrong header: %v != %v\n", r.Header(), expectedHeader)
+	}
+
+	r = cf.NewReader(strings.NewReader(in), "in", "d")
+	if r.Next() {
+		t.Fatalf("Unexpected record: %v\n", r.Record())
+	}
+	if r.Err() == nil || r.Err().Error() != "in: Column name error: 'd' not found in header!" {
+		t.Errorf("Unexpected error: %v\n", r.Err())
+	}
+
+	cf.FilterZero = true
+	r = cf.NewReader(strings.NewReader("x\n0\n1.5\n"), "in")
+	var fdata []float64
+	for r.Next() {
+		row, err := r.Float64Record()
+		if err != nil {
+			t.Fatalf("Unexpected error: %s\n", err)
+		}
+		fdata = append(fdata, row...)
+	}
+	if len(fdata) != 2 || !math.IsNaN(fdata[0]) || fdata[1] != 1.5 {
+		t.Errorf("Wrong data: %v\n", fdata)
+	}
+}
+
+// tempFile - Writes `data` to a temporary file removed when the test ends and returns its name.
+func tempFile(t *testing.T, data string) string {
+	fh, err := ioutil.TempFile("", "csvutil")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s\n", err)
+	}
+	t.Cleanup(func() { os.Remove(fh.Name()) })
+	fh.WriteString(data)
+	fh.Close()
+	return fh.Name()
+}
+
+func TestGetFloat64Rows(t *testing.T) {
+	file := tempFile(t, `x,y
+1,10
+2,0
+3,30
+,40
+5,50
+`)
+	cf := New(file)
+	cf.FilterZero = true
+	columns, err := cf.GetFloat64ColumnsByName("x", "y")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s\n", err)
+	}
+	expected := [][]float64{[]float64{1, 3, 5}, []float64{10, 30, 50}}
+	if !reflect.DeepEqual(columns, expected) {
+		t.Errorf("Wrong data: %v != %v\n", columns, expected)
+	}
+	cf.Missing = Interpolate
+	rows, err := cf.GetFloat64Rows("x", "y")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s\n", err)
+	}
+	expectedRows := [][]float64{[]float64{1, 10}, []float64{2, 20}, []float64{3, 30}, []float64{4, 40}, []float64{5, 50}}
+	if !reflect.DeepEqual(rows, expectedRows) {
+		t.Errorf("Wrong data: %v != %v\n", rows, expectedRows)
+	}
+	cf.Missing = DropRow
+	rows, positions, err := cf.GetFloat64RowsWithPositions("y")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s\n", err)
+	}
+	expectedRows = [][]float64{[]float64{10}, []float64{30}, []float64{40}, []float64{50}}
+	expectedPositions := []Position{{file, 2}, {file, 4}, {file, 5}, {file, 6}}
+	if !reflect.DeepEqual(rows, expectedRows) || !reflect.DeepEqual(positions, expectedPositions) {
+		t.Errorf("Wrong data: %v %v != %v %v\n", rows, positions, expectedRows, expectedPositions)
+	}
+	if positions[0].String() != file+":2" {
+		t.Errorf("Wrong position: %s\n", positions[0])
+	}
+}
+
+func TestSourceColumn(t *testing.T) {
+	var files []string
+	for _, data := range []string{"x\n1\n2\n", "x\n3\n"} {
+		files = append(files, tempFile(t, data))
+	}
+	cf := New(files...)
+	columns, err := cf.GetCSVColumnsByName("source", "x")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s\n", err)
+	}
+	expected := [][]string{[]string{files[0], files[0], files[1]}, []string{"1", "2", "3"}}
+	if !reflect.DeepEqual(columns, expected) {
+		t.Errorf("Wrong data: %v != %v\n", columns, expected)
+	}
+
+	cf.Where, _ = ParseExpression("source == '" + files[1] + "'")
+	c, _ := ParseComputedColumn("label=source")
+	cf.Computed = []ComputedColumn{c}
+	columns, err = cf.GetCSVColumnsByName("label", "x")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s\n", err)
+	}
+	expected = [][]string{[]string{files[1]}, []string{"3"}}
+	if !reflect.DeepEqual(columns, expected) {
+		t.Errorf("Wrong data: %v != %v\n", columns, expected)
+	}
+
+	// A column of the file takes precedence.
+	cf = New()
+	r := cf.NewReader(strings.NewReader("Source,x\na,1\n"), "in", "source")
+	var rdata [][]string
+	for r.Next() {
+		rdata = append(rdata, r.Record())
+	}
+	if err := r.Err(); err != nil {
+		t.Fatalf("Unexpected error: %s\n", err)
+	}
+	if !reflect.DeepEqual(rdata, [][]string{[]string{"a"}}) {
+		t.Errorf("Wrong data: %v\n", rdata)
+	}
+}
@@ -0,0 +1,279 @@
+// This file is part of csv-analysis.
+//
+// Copyright (C) 2017  David Gamba Rios
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package csvutil
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// ColumnType - Type inferred from the values of a column.
+type ColumnType int
+
+// Column types.
+const (
+	Empty ColumnType = iota
+	Boolean
+	Integer
+	Float
+	Timestamp
+	Categorical
+	Text
+)
+
+var columnTypeNames = []string{"empty", "boolean", "integer", "float", "timestamp", "categorical", "text"}
+
+func (t ColumnType) String() string {
+	return columnTypeNames[t]
+}
+
+// TimestampLayouts - Layouts tried, in order, when inferring timestamp columns.
+var TimestampLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05Z07:00",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+	"2006/01/02 15:04:05",
+	"2006/01/02",
+	"01/02/2006 15:04:05",
+	"01/02/2006",
+	"02.01.2006 15:04:05",
+	"02.01.2006",
+	time.RFC1123Z,
+	time.RFC1123,
+	time.UnixDate,
+	time.ANSIC,
+	time.Stamp,
+}
+
+// nullValues - Values considered null, compared case-insensitively.
+var nullValues = map[string]bool{"": true, "na": true, "n/a": true, "null": true, "none": true, "nil": true, "-": true}
+
+// Describe limits.
+const (
+	// describeSamples - Number of distinct sample values kept per column.
+	describeSamples = 5
+	// describeMaxDistinct - Distinct values are counted up to this number.
+	describeMaxDistinct = 10000
+	// describeMaxCategories - Maximum number of distinct values of a categorical column.
+	describeMaxCategories = 20
+)
+
+// ColumnSummary - Inferred schema and statistics of a column.
+type ColumnSummary struct {
+	Index int // 1-based column index.
+	Name  string
+	Type  ColumnType
+	// Detected layout of Timestamp columns, see TimestampLayouts.
+	Layout string
+	// Number of non null values.
+	Count int
+	// Number of null values, see nullValues.
+	Nulls int
+	// Number of non null values that don't match the inferred type.
+	Unparsable int
+	// Number of distinct non null values, counted up to describeMaxDistinct.
+	Distinct int
+	// Min and Max values, as found in the file, compared according to the inferred type.
+	Min, Max string
+	// First distinct non null values.
+	Samples []string
+
+	distinct map[string]bool
+	matches  [Timestamp + 1]int
+	numMin   numExtreme
+	numMax   numExtreme
+	layouts  []layoutStats
+	strMin   string
+	strMax   string
+}
+
+type numExtreme struct {
+	value float64
+	raw   string
+	set   bool
+}
+
+type layoutStats struct {
+	matches  int
+	min, max time.Time
+	rawMin   string
+	rawMax   string
+}
+
+// Describe - Scans the files and returns the inferred schema and statistics of every column.
+// Columns are matched by index across files, the names come from the header of the first file.
+// Numbers are parsed with Parsers or with Parser.
+func (cf *CSVFiles) Describe() ([]*ColumnSummary, error) {
+	var summaries []*ColumnSummary
+	err := cf.eachReader(nil, func(r *Reader) error {
+		var err error
+		summaries, err = cf.describe(summaries, r)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range summaries {
+		s.infer()
+	}
+	return summaries, nil
+}
+
+// describe - Adds the values read from `r` to the summaries.
+func (cf *CSVFiles) describe(summaries []*ColumnSummary, r *Reader) ([]*ColumnSummary, error) {
+	for r.Next() {
+		for i, value := range r.Raw() {
+			if i >= len(summaries) {
+				summaries = append(summaries, cf.newColumnSummary(i+1, r.Header()))
+			}
+			s := summaries[i]
+			s.add(r.parser(s.Index), value)
+		}
+	}
+	for i := len(summaries); i < len(r.Header()); i++ {
+		summaries = append(summaries, cf.newColumnSummary(i+1, r.Header()))
+	}
+	return summaries, r.Err()
+}
+
+// newColumnSummary - Returns an empty summary for the given 1-based column index.
+func (cf *CSVFiles) newColumnSummary(index int, header []string) *ColumnSummary {
+	s := &ColumnSummary{
+		Index:    index,
+		Name:     strconv.Itoa(index),
+		distinct: make(map[string]bool),
+		layouts:  make([]layoutStats, len(TimestampLayouts)),
+	}
+	if len(header) >= index {
+		s.Name = header[index-1]
+	}
+	return s
+}
+
+// add - Adds a value to the summary, numbers are parsed with the given parse function.
+func (s *ColumnSummary) add(parse func(string) (float64, error), value string) {
+	value = strings.TrimSpace(value)
+	if nullValues[strings.ToLower(value)] {
+		s.Nulls++
+		return
+	}
+	s.Count++
+	if !s.distinct[value] {
+		if len(s.distinct) < describeMaxDistinct {
+			s.distinct[value] = true
+		}
+		if len(s.Samples) < describeSamples {
+			s.Samples = append(s.Samples, value)
+		}
+	}
+	if s.Count == 1 || value < s.strMin {
+		s.strMin = value
+	}
+	if s.Count == 1 || value > s.strMax {
+		s.strMax = value
+	}
+	switch strings.ToLower(value) {
+	case "true", "false", "yes", "no", "t", "f", "y", "n":
+		s.matches[Boolean]++
+	}
+	if _, err := strconv.ParseInt(value, 10, 64); err == nil {
+		s.matches[Integer]++
+	}
+	if x, err := parse(value); err == nil && !math.IsNaN(x) {
+		s.matches[Float]++
+		if !s.numMin.set || x < s.numMin.value {
+			s.numMin = numExtreme{x, value, true}
+		}
+		if !s.numMax.set || x > s.numMax.value {
+			s.numMax = numExtreme{x, value, true}
+		}
+	}
+	for i, layout := range TimestampLayouts {
+		t, err := time.Parse(layout, value)
+		if err != nil {
+			continue
+		}
+		l := &s.layouts[i]
+		if l.matches == 0 || t.Before(l.min) {
+			l.min, l.rawMin = t, value
+		}
+		if l.matches == 0 || t.After(l.max) {
+			l.max, l.rawMax = t, value
+		}
+		l.matches++
+	}
+}
+
+// infer - Sets the type, counts and extremes from the values added.
+// The type is the one that matches the most values, in case of a tie the first one of boolean, integer, float and timestamp.
+// When less than half of the values match any type, the column is categorical or text.
+func (s *ColumnSummary) infer() {
+	s.Distinct = len(s.distinct)
+	if s.Count == 0 {
+		s.Type = Empty
+		return
+	}
+	best := 0
+	for i, l := range s.layouts {
+		if l.matches > s.layouts[best].matches {
+			best = i
+		}
+	}
+	s.matches[Timestamp] = s.layouts[best].matches
+	s.Type = Text
+	matches := 0
+	for _, t := range []ColumnType{Boolean, Integer, Float, Timestamp} {
+		if s.matches[t] > matches {
+			s.Type, matches = t, s.matches[t]
+		}
+	}
+	if matches*2 < s.Count {
+		s.Type, matches = Text, s.Count
+		if s.Distinct <= describeMaxCategories && s.Distinct*2 <= s.Count {
+			s.Type = Categorical
+		}
+	}
+	s.Unparsable = s.Count - matches
+	switch s.Type {
+	case Integer, Float:
+		s.Min, s.Max = s.numMin.raw, s.numMax.raw
+	case Timestamp:
+		s.Layout = TimestampLayouts[best]
+		s.Min, s.Max = s.layouts[best].rawMin, s.layouts[best].rawMax
+	default:
+		s.Min, s.Max = s.strMin, s.strMax
+	}
+}
+
+// PrintColumnSummaries - Prints the column summaries to `w` as a table.
+func PrintColumnSummaries(w io.Writer, summaries []*ColumnSummary) {
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintf(tw, "Column\tName\tType\tCount\tNulls\tUnparsable\tDistinct\tMin\tMax\tSamples\n")
+	for _, s := range summaries {
+		t := s.Type.String()
+		if s.Layout != "" {
+			t += fmt.Sprintf(" (%s)", s.Layout)
+		}
+		distinct := strconv.Itoa(s.Distinct)
+		if s.Distinct >= describeMaxDistinct {
+			distinct = ">=" + distinct
+		}
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%d\t%d\t%d\t%s\t%s\t%s\t%s\n",
+			s.Index, s.Name, t, s.Count, s.Nulls, s.Unparsable, distinct, s.Min, s.Max, strings.Join(s.Samples, ", "))
+	}
+	tw.Flush()
+}
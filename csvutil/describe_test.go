@@ -0,0 +1,72 @@
+// This file is part of csv-analysis.
+//
+// Copyright (C) 2017  David Gamba Rios
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+package csvutil
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDescribe(t *testing.T) {
+	in := `time,count,latency,ok,status,message,unused
+2018-01-02 09:00:00,10,1.5,true,ok,a,
+2018-01-01 10:00:00,12,0.5,false,error,b,
+2018-01-01 11:00:00,-3,2,TRUE,ok,c,NA
+2018-01-01 12:00:00,x,1e2,FALSE,ok,d,
+2018-01-01 13:00:00,7,3,yes,error,e,
+2018-01-01 14:00:00,NULL,3,no,ok,,
+`
+	cf := New()
+	summaries, err := cf.describe(nil, cf.NewReader(strings.NewReader(in), "test.csv"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %s\n", err)
+	}
+	for _, s := range summaries {
+		s.infer()
+	}
+	expected := []ColumnSummary{
+		{Index: 1, Name: "time", Type: Timestamp, Layout: "2006-01-02 15:04:05", Count: 6, Distinct: 6,
+			Min: "2018-01-01 10:00:00", Max: "2018-01-02 09:00:00",
+			Samples: []string{"2018-01-02 09:00:00", "2018-01-01 10:00:00", "2018-01-01 11:00:00", "2018-01-01 12:00:00", "2018-01-01 13:00:00"}},
+		{Index: 2, Name: "count", Type: Integer, Count: 5, Nulls: 1, Unparsable: 1, Distinct: 5,
+			Min: "-3", Max: "12",
+			Samples: []string{"10", "12", "-3", "x", "7"}},
+		{Index: 3, Name: "latency", Type: Float, Count: 6, Distinct: 5,
+			Min: "0.5", Max: "1e2",
+			Samples: []string{"1.5", "0.5", "2", "1e2", "3"}},
+		{Index: 4, Name: "ok", Type: Boolean, Count: 6, Distinct: 6,
+			Min: "FALSE", Max: "yes",
+			Samples: []string{"true", "false", "TRUE", "FALSE", "yes"}},
+		{Index: 5, Name: "status", Type: Categorical, Count: 6, Distinct: 2,
+			Min: "error", Max: "ok",
+			Samples: []string{"ok", "error"}},
+		{Index: 6, Name: "message", Type: Text, Count: 5, Nulls: 1, Distinct: 5,
+			Min: "a", Max: "e",
+			Samples: []string{"a", "b", "c", "d", "e"}},
+		{Index: 7, Name: "unused", Type: Empty, Nulls: 6},
+	}
+	if len(summaries) != len(expected) {
+		t.Fatalf("Wrong number of columns: %d != %d\n", len(summaries), len(expected))
+	}
+	for i, s := range summaries {
+		e := expected[i]
+		got := ColumnSummary{Index: s.Index, Name: s.Name, Type: s.Type, Layout: s.Layout, Count: s.Count, Nulls: s.Nulls,
+			Unparsable: s.Unparsable, Distinct: s.Distinct, Min: s.Min, Max: s.Max, Samples: s.Samples}
+		if !reflect.DeepEqual(got, e) {
+			t.Errorf("Wrong summary:\n%+v !=\n%+v\n", got, e)
+		}
+	}
+	var buf bytes.Buffer
+	PrintColumnSummaries(&buf, summaries[:1])
+	lines := strings.Split(buf.String(), "\n")
+	if len(lines) != 3 || !strings.HasPrefix(lines[1], "1       time  timestamp (2006-01-02 15:04:05)  6") {
+		t.Errorf("Wrong output: %q\n", buf.String())
+	}
+}
@@ -0,0 +1,149 @@
+// This file is part of csv-analysis.
+//
+// Copyright (C) 2017  David Gamba Rios
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package csvutil
+
+import (
+	"bufio"
+	"encoding/csv"
+	"io"
+	"strings"
+)
+
+// Dialect - CSV format settings.
+// The quote character is always '"', encoding/csv doesn't allow to change it.
+type Dialect struct {
+	// Field delimiter. When 0, it is sniffed from the first rows of each file.
+	Delimiter rune
+	// Lines starting with the Comment character are ignored. When 0, comments are disabled.
+	Comment rune
+	// Allows quotes in unquoted fields and non-doubled quotes in quoted fields.
+	LazyQuotes bool
+	// Ignores leading white space in fields.
+	TrimLeadingSpace bool
+	// Number of preamble lines to skip before the CSV data starts.
+	SkipLines int
+}
+
+// sniffDelimiters - Candidate delimiters in order of preference.
+var sniffDelimiters = []rune{',', '\t', ';', '|'}
+
+// sniffRows - Number of rows used to sniff the delimiter.
+const sniffRows = 10
+
+// sniffSize - Maximum number of bytes used to sniff the delimiter.
+const sniffSize = 64 * 1024
+
+// newCSVReader - Returns a *csv.Reader for `reader` configured with the given dialect.
+// Preamble lines are skipped and, if no delimiter was given, it is sniffed from the first rows.
+func newCSVReader(reader io.Reader, d Dialect) (*csv.Reader, error) {
+	br := bufio.NewReaderSize(reader, sniffSize)
+	for i := 0; i < d.SkipLines; i++ {
+		_, err := br.ReadString('\n')
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	if d.Delimiter == 0 {
+		d.Delimiter = sniffDelimiter(br, d.Comment)
+	}
+	r := csv.NewReader(br)
+	r.FieldsPerRecord = -1
+	r.Comma = d.Delimiter
+	r.Comment = d.Comment
+	r.LazyQuotes = d.LazyQuotes
+	r.TrimLeadingSpace = d.TrimLeadingSpace
+	return r, nil
+}
+
+// sniffDelimiter - Peeks at the first rows of `br` and returns the most likely delimiter.
+// The delimiter that splits every sampled row into the same, and largest, number of fields wins.
+// ',' loses to ';' when ';' also splits every row the same way and every ',' is between digits, like the decimal commas of European exports, "1,5;2,5".
+// Defaults to ',' when there is no clear winner.
+func sniffDelimiter(br *bufio.Reader, comment rune) rune {
+	sample := peek(br, sniffSize)
+	split := strings.Split(string(sample), "\n")
+	if len(sample) == sniffSize {
+		// Drop the last line, it is most likely incomplete.
+		split = split[:len(split)-1]
+	}
+	var lines []string
+	for _, line := range split {
+		line = strings.TrimRight(line, "\r")
+		if line == "" || (comment != 0 && strings.HasPrefix(line, string(comment))) {
+			continue
+		}
+		lines = append(lines, line)
+		if len(lines) >= sniffRows {
+			break
+		}
+	}
+	best, bestFields := ',', 1
+	consistent := make(map[rune]bool)
+	for _, delimiter := range sniffDelimiters {
+		fields := -1
+		for _, line := range lines {
+			n := countFields(line, delimiter)
+			if fields == -1 {
+				fields = n
+			}
+			if n != fields {
+				fields = 0
+				break
+			}
+		}
+		consistent[delimiter] = fields > 1
+		if fields > bestFields {
+			best, bestFields = delimiter, fields
+		}
+	}
+	if best == ',' && consistent[';'] && decimalCommas(lines) {
+		return ';'
+	}
+	return best
+}
+
+// decimalCommas - Indicates if every ',' outside quotes in the lines is between two digits.
+func decimalCommas(lines []string) bool {
+	for _, line := range lines {
+		quoted := false
+		for i := 0; i < len(line); i++ {
+			switch {
+			case line[i] == '"':
+				quoted = !quoted
+			case line[i] == ',' && !quoted:
+				if i == 0 || i == len(line)-1 || !isDigit(line[i-1]) || !isDigit(line[i+1]) {
+					return false
+				}
+			}
+		}
+	}
+	return true
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// countFields - Returns the number of fields in `line` ignoring delimiters inside quotes.
+func countFields(line string, delimiter rune) int {
+	n := 1
+	quoted := false
+	for _, c := range line {
+		switch {
+		case c == '"':
+			quoted = !quoted
+		case c == delimiter && !quoted:
+			n++
+		}
+	}
+	return n
+}
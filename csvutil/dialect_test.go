@@ -0,0 +1,75 @@
+// This file is part of csv-analysis.
+//
+// Copyright (C) 2017  David Gamba Rios
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+package csvutil
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSniffDelimiter(t *testing.T) {
+	tests := []struct {
+		in       string
+		expected rune
+	}{
+		{"a,b,c\n1,2,3\n", ','},
+		{"a\tb\tc\n1\t2\t3\n", '\t'},
+		{"a;b;c\n1,5;2,5;3,5\n", ';'},
+		{"a|b\n1|2\n", '|'},
+		{"\"a;b\",c\n\"1;2\",3\n", ','},
+		{"# comment; with, delimiters\na;b\n1;2\n", ';'},
+		{"a\n1\n", ','},
+		// Headerless decimal commas.
+		{"1,5;2,5\n3,25;4,75\n", ';'},
+		{"1,5;2,5;3\n3,25;4,75;6\n", ';'},
+		{"1,2,3\n4,5,6\n", ','},
+	}
+	for _, test := range tests {
+		r, err := newCSVReader(strings.NewReader(test.in), Dialect{Comment: '#'})
+		if err != nil {
+			t.Fatalf("Unexpected error: %s\n", err)
+		}
+		if r.Comma != test.expected {
+			t.Errorf("Wrong delimiter for %q: %q != %q\n", test.in, r.Comma, test.expected)
+		}
+	}
+}
+
+func TestDialect(t *testing.T) {
+	in := `Exported by vendor tool
+generated: today
+# a comment
+a; b; c
+1,5; 2; 3
+`
+	d := Dialect{Comment: '#', TrimLeadingSpace: true, SkipLines: 2}
+	cdata, err := getCSVColumns(strings.NewReader(in), d, 1, 2)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s\n", err)
+	}
+	expected := [][]string{[]string{"a", "1,5"}, []string{"b", "2"}}
+	if !reflect.DeepEqual(cdata, expected) {
+		t.Errorf("Wrong data: %v != %v\n", cdata, expected)
+	}
+	in = `a,b
+1 "x",2
+`
+	_, err = getCSVColumns(strings.NewReader(in), Dialect{Delimiter: ','}, 1)
+	if err == nil {
+		t.Fatalf("Expected error not thrown\n")
+	}
+	cdata, err = getCSVColumns(strings.NewReader(in), Dialect{Delimiter: ',', LazyQuotes: true}, 1)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s\n", err)
+	}
+	expected = [][]string{[]string{"a", `1 "x"`}}
+	if !reflect.DeepEqual(cdata, expected) {
+		t.Errorf("Wrong data: %v != %v\n", cdata, expected)
+	}
+}
@@ -0,0 +1,842 @@
+// This file is part of csv-analysis.
+//
+// Copyright (C) 2017  David Gamba Rios
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package csvutil
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Expression - Parsed expression that is evaluated on each record, see ParseExpression.
+type Expression struct {
+	source  string
+	root    node
+	columns []string
+}
+
+// ParseExpression - Parses an expression like `status == "200" and region == "eu"`.
+//
+// Columns are referenced by name, `latency` or `metrics.latency.p99`, by quoted name, `my column`, or by 1-based index, $3.
+// Names are resolved against the header of each file, see resolveColumn.
+// Literals are numbers, strings in single or double quotes, true and false.
+//
+// Operators, from lowest to highest precedence:
+//
+//   - or, ||
+//   - and, &&
+//   - not, !
+//   - ==, =, !=, <, <=, >, >=, =~ and !~ regex matches, `in lo..hi` inclusive ranges and `in (a, b, c)` lists.
+//     Use `not in` to negate ranges and lists.
+//   - +, -
+//   - *, /, % modulo
+//   - - negation
+//   - ^ power, right associative
+//
+// Values are compared as numbers when both sides are numbers, a column value is a number when it can be parsed with the column parser.
+// A number can't be equal to, or ordered against, a value that is not a number, so `latency < 100` is false for empty values.
+// Otherwise values are compared as strings.
+//
+// Arithmetic on values that are not numbers results in NaN.
+// The functions abs, ceil, floor, round, sqrt, exp, log, log2, log10, pow(x, y), min(a, b...) and max(a, b...) are available,
+// as well as time(x[, layout]) that parses a timestamp into seconds since the Unix epoch, see timeFunction.
+func ParseExpression(s string) (*Expression, error) {
+	tokens, err := lex(s)
+	if err != nil {
+		return nil, fmt.Errorf("expression '%s': %s", s, err)
+	}
+	e := &Expression{source: s}
+	p := &exprParser{tokens: tokens, expression: e}
+	e.root, err = p.parseOr()
+	if err == nil && p.peek().kind != tokEOF {
+		err = p.unexpected()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("expression '%s': %s", s, err)
+	}
+	return e, nil
+}
+
+func (e *Expression) String() string {
+	return e.source
+}
+
+// Columns - Returns the column specs referenced by the expression.
+func (e *Expression) Columns() []string {
+	return e.columns
+}
+
+// bind - Returns an evaluation context for the records of `r` with the referenced columns resolved against the header.
+func (e *Expression) bind(r *Reader, header []string) (*evalContext, error) {
+	ctx := &evalContext{r: r, indexes: make(map[string]int)}
+	for _, spec := range e.columns {
+		index, err := resolveColumn(header, spec)
+		if err != nil {
+			return nil, err
+		}
+		if index <= 0 {
+			return nil, fmt.Errorf("Column index error: %d <= 0!", index)
+		}
+		ctx.indexes[spec] = index
+	}
+	return ctx, nil
+}
+
+// value - Returns the result of the expression for the record as a string.
+// Numbers that are NaN or infinite are returned as an empty string, a missing value.
+func (e *Expression) value(ctx *evalContext, record []string) string {
+	ctx.record = record
+	v := e.root.eval(ctx)
+	if v.kind == numberValue && (math.IsNaN(v.num) || math.IsInf(v.num, 0)) {
+		return ""
+	}
+	return v.str
+}
+
+// match - Indicates if the expression is true for the record.
+func (e *Expression) match(ctx *evalContext, record []string) bool {
+	ctx.record = record
+	return e.root.eval(ctx).truthy()
+}
+
+// evalContext - Record being evaluated and the indexes of the referenced columns.
+type evalContext struct {
+	r       *Reader
+	indexes map[string]int
+	record  []string
+}
+
+type valueKind int
+
+const (
+	stringValue valueKind = iota
+	numberValue
+	boolValue
+)
+
+// value - Result of evaluating a node.
+// Column values are strings that can also be a number, see hasNum.
+type value struct {
+	kind   valueKind
+	str    string
+	num    float64
+	hasNum bool
+	b      bool
+}
+
+func numberVal(x float64) value {
+	return value{kind: numberValue, str: strconv.FormatFloat(x, 'g', -1, 64), num: x, hasNum: true}
+}
+
+func boolVal(b bool) value {
+	return value{kind: boolValue, str: strconv.FormatBool(b), b: b}
+}
+
+// numeric - Indicates if the value can be used as a number.
+func (v value) numeric() bool {
+	return v.hasNum
+}
+
+// truthy - Booleans are themselves, numbers are true when not 0 and strings when not empty.
+func (v value) truthy() bool {
+	switch v.kind {
+	case boolValue:
+		return v.b
+	case numberValue:
+		return v.num != 0 && !math.IsNaN(v.num)
+	}
+	return v.str != ""
+}
+
+// compareValues - Returns -1, 0 or 1 comparing a and b, and false when they can't be compared.
+func compareValues(a, b value) (int, bool) {
+	switch {
+	case a.numeric() && b.numeric():
+		if math.IsNaN(a.num) || math.IsNaN(b.num) {
+			return 0, false
+		}
+		switch {
+		case a.num < b.num:
+			return -1, true
+		case a.num > b.num:
+			return 1, true
+		}
+		return 0, true
+	case a.kind == numberValue || b.kind == numberValue:
+		return 0, false
+	case a.kind == boolValue || b.kind == boolValue:
+		x, errA := strconv.ParseBool(a.str)
+		y, errB := strconv.ParseBool(b.str)
+		if errA != nil || errB != nil {
+			return 0, false
+		}
+		if x == y {
+			return 0, true
+		}
+		if !x {
+			return -1, true
+		}
+		return 1, true
+	}
+	return strings.Compare(a.str, b.str), true
+}
+
+// node - Expression tree node.
+type node interface {
+	eval(ctx *evalContext) value
+}
+
+type literalNode struct {
+	v value
+}
+
+func (n *literalNode) eval(ctx *evalContext) value {
+	return n.v
+}
+
+type columnNode struct {
+	spec string
+}
+
+func (n *columnNode) eval(ctx *evalContext) value {
+	v := value{kind: stringValue}
+	index := ctx.indexes[n.spec]
+	if len(ctx.record) >= index {
+		v.str = ctx.record[index-1]
+	}
+	trimmed := strings.TrimSpace(v.str)
+	if trimmed != "" {
+		if x, err := ctx.r.parser(index)(trimmed); err == nil {
+			v.num, v.hasNum = x, true
+		}
+	}
+	return v
+}
+
+type logicalNode struct {
+	and         bool
+	left, right node
+}
+
+func (n *logicalNode) eval(ctx *evalContext) value {
+	left := n.left.eval(ctx).truthy()
+	if n.and != left {
+		return boolVal(left)
+	}
+	return boolVal(n.right.eval(ctx).truthy())
+}
+
+type notNode struct {
+	operand node
+}
+
+func (n *notNode) eval(ctx *evalContext) value {
+	return boolVal(!n.operand.eval(ctx).truthy())
+}
+
+type compareNode struct {
+	op          string
+	left, right node
+}
+
+func (n *compareNode) eval(ctx *evalContext) value {
+	c, ok := compareValues(n.left.eval(ctx), n.right.eval(ctx))
+	if !ok {
+		return boolVal(n.op == "!=")
+	}
+	switch n.op {
+	case "==":
+		return boolVal(c == 0)
+	case "!=":
+		return boolVal(c != 0)
+	case "<":
+		return boolVal(c < 0)
+	case "<=":
+		return boolVal(c <= 0)
+	case ">":
+		return boolVal(c > 0)
+	}
+	return boolVal(c >= 0)
+}
+
+type matchNode struct {
+	negate  bool
+	operand node
+	re      *regexp.Regexp
+}
+
+func (n *matchNode) eval(ctx *evalContext) value {
+	return boolVal(n.re.MatchString(n.operand.eval(ctx).str) != n.negate)
+}
+
+type rangeNode struct {
+	negate          bool
+	operand, lo, hi node
+}
+
+func (n *rangeNode) eval(ctx *evalContext) value {
+	v := n.operand.eval(ctx)
+	lo, okLo := compareValues(n.lo.eval(ctx), v)
+	hi, okHi := compareValues(v, n.hi.eval(ctx))
+	return boolVal((okLo && okHi && lo <= 0 && hi <= 0) != n.negate)
+}
+
+type listNode struct {
+	negate  bool
+	operand node
+	list    []node
+}
+
+func (n *listNode) eval(ctx *evalContext) value {
+	v := n.operand.eval(ctx)
+	for _, e := range n.list {
+		if c, ok := compareValues(v, e.eval(ctx)); ok && c == 0 {
+			return boolVal(!n.negate)
+		}
+	}
+	return boolVal(n.negate)
+}
+
+type arithmeticNode struct {
+	op          string
+	left, right node
+}
+
+// eval - Values that are not numbers result in NaN.
+func (n *arithmeticNode) eval(ctx *evalContext) value {
+	a, b := n.left.eval(ctx), n.right.eval(ctx)
+	if !a.numeric() || !b.numeric() {
+		return numberVal(math.NaN())
+	}
+	switch n.op {
+	case "+":
+		return numberVal(a.num + b.num)
+	case "-":
+		return numberVal(a.num - b.num)
+	case "*":
+		return numberVal(a.num * b.num)
+	case "/":
+		return numberVal(a.num / b.num)
+	case "%":
+		return numberVal(math.Mod(a.num, b.num))
+	}
+	return numberVal(math.Pow(a.num, b.num))
+}
+
+// function - Function callable from expressions with at least min arguments and at most max, -1 for no limit.
+type function struct {
+	min, max int
+	f        func(args []value) value
+}
+
+// math1 - Returns a function of a single number.
+func math1(f func(float64) float64) function {
+	return function{1, 1, func(args []value) value {
+		if !args[0].numeric() {
+			return numberVal(math.NaN())
+		}
+		return numberVal(f(args[0].num))
+	}}
+}
+
+// fold - Returns a function that folds its numeric arguments with f.
+func fold(f func(a, b float64) float64) function {
+	return function{1, -1, func(args []value) value {
+		for _, arg := range args {
+			if !arg.numeric() {
+				return numberVal(math.NaN())
+			}
+		}
+		x := args[0].num
+		for _, arg := range args[1:] {
+			x = f(x, arg.num)
+		}
+		return numberVal(x)
+	}}
+}
+
+// functions - Functions callable from expressions, by lower case name.
+var functions = map[string]function{
+	"abs":   math1(math.Abs),
+	"ceil":  math1(math.Ceil),
+	"floor": math1(math.Floor),
+	"round": math1(math.Round),
+	"sqrt":  math1(math.Sqrt),
+	"exp":   math1(math.Exp),
+	"log":   math1(math.Log),
+	"log2":  math1(math.Log2),
+	"log10": math1(math.Log10),
+	"pow":   {2, 2, fold(math.Pow).f},
+	"min":   fold(math.Min),
+	"max":   fold(math.Max),
+	"time":  {1, 2, timeFunction},
+}
+
+// timeFunction - Parses a timestamp into seconds since the Unix epoch.
+// The layout is the second argument or, when missing, the first one of TimestampLayouts that parses the value.
+func timeFunction(args []value) value {
+	layouts := TimestampLayouts
+	if len(args) > 1 {
+		layouts = []string{args[1].str}
+	}
+	s := strings.TrimSpace(args[0].str)
+	for _, layout := range layouts {
+		t, err := time.Parse(layout, s)
+		if err == nil {
+			return numberVal(float64(t.UnixNano()) / 1e9)
+		}
+	}
+	return numberVal(math.NaN())
+}
+
+type callNode struct {
+	name string
+	f    function
+	args []node
+}
+
+func (n *callNode) eval(ctx *evalContext) value {
+	args := make([]value, len(n.args))
+	for i, arg := range n.args {
+		args[i] = arg.eval(ctx)
+	}
+	return n.f.f(args)
+}
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokNumber
+	tokString
+	tokIdent
+	tokColumn
+	tokOp
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+// operators - Operators, longest first.
+var operators = []string{"==", "!=", "<=", ">=", "=~", "!~", "&&", "||", "..", "<", ">", "=", "!", "(", ")", ",", "+", "-", "*", "/", "%", "^"}
+
+// lex - Splits the expression into tokens.
+func lex(s string) ([]token, error) {
+	var tokens []token
+	i := 0
+	for i < len(s) {
+		c, size := utf8.DecodeRuneInString(s[i:])
+		start := i
+		switch {
+		case unicode.IsSpace(c):
+			i += size
+			continue
+		case c >= '0' && c <= '9' || c == '.' && i+1 < len(s) && s[i+1] >= '0' && s[i+1] <= '9':
+			i = lexNumber(s, i)
+			tokens = append(tokens, token{tokNumber, s[start:i], start})
+			continue
+		case c == '"' || c == '\'':
+			var b strings.Builder
+			i++
+			for ; i < len(s) && rune(s[i]) != c; i++ {
+				if s[i] == '\\' && i+1 < len(s) && (rune(s[i+1]) == c || s[i+1] == '\\') {
+					i++
+				}
+				b.WriteByte(s[i])
+			}
+			if i >= len(s) {
+				return nil, fmt.Errorf("unterminated string at position %d", start+1)
+			}
+			i++
+			tokens = append(tokens, token{tokString, b.String(), start})
+			continue
+		case c == '`':
+			end := strings.IndexByte(s[i+1:], '`')
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated column name at position %d", start+1)
+			}
+			i += end + 2
+			tokens = append(tokens, token{tokColumn, s[start+1 : i-1], start})
+			continue
+		case c == '$':
+			i++
+			for i < len(s) && s[i] >= '0' && s[i] <= '9' {
+				i++
+			}
+			if i == start+1 {
+				return nil, fmt.Errorf("missing column index after '$' at position %d", start+1)
+			}
+			tokens = append(tokens, token{tokColumn, s[start+1 : i], start})
+			continue
+		case unicode.IsLetter(c) || c == '_':
+			for i < len(s) {
+				c, size := utf8.DecodeRuneInString(s[i:])
+				if !unicode.IsLetter(c) && !unicode.IsDigit(c) && c != '_' && (c != '.' || strings.HasPrefix(s[i:], "..")) {
+					break
+				}
+				i += size
+			}
+			tokens = append(tokens, token{tokIdent, s[start:i], start})
+			continue
+		}
+		found := false
+		for _, op := range operators {
+			if strings.HasPrefix(s[i:], op) {
+				tokens = append(tokens, token{tokOp, op, start})
+				i += len(op)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("unexpected '%c' at position %d", c, start+1)
+		}
+	}
+	return append(tokens, token{tokEOF, "", len(s)}), nil
+}
+
+// lexNumber - Returns the end of the number starting at i.
+func lexNumber(s string, i int) int {
+	digits := func() {
+		for i < len(s) && s[i] >= '0' && s[i] <= '9' {
+			i++
+		}
+	}
+	digits()
+	if i < len(s) && s[i] == '.' && !strings.HasPrefix(s[i:], "..") {
+		i++
+		digits()
+	}
+	if i < len(s) && (s[i] == 'e' || s[i] == 'E') {
+		j := i + 1
+		if j < len(s) && (s[j] == '+' || s[j] == '-') {
+			j++
+		}
+		if j < len(s) && s[j] >= '0' && s[j] <= '9' {
+			i = j
+			digits()
+		}
+	}
+	return i
+}
+
+// exprParser - Recursive descent parser for expressions.
+type exprParser struct {
+	tokens     []token
+	pos        int
+	expression *Expression
+}
+
+func (p *exprParser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *exprParser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokEOF {
+		p.pos++
+	}
+	return t
+}
+
+// is - Indicates if the next token is the given operator or case-insensitive keyword.
+func (p *exprParser) is(texts ...string) bool {
+	t := p.peek()
+	for _, text := range texts {
+		if t.kind == tokOp && t.text == text || t.kind == tokIdent && strings.EqualFold(t.text, text) {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *exprParser) unexpected() error {
+	t := p.peek()
+	if t.kind == tokEOF {
+		return fmt.Errorf("unexpected end of expression")
+	}
+	return fmt.Errorf("unexpected '%s' at position %d", t.text, t.pos+1)
+}
+
+func (p *exprParser) expect(text string) error {
+	if !p.is(text) {
+		return p.unexpected()
+	}
+	p.next()
+	return nil
+}
+
+func (p *exprParser) parseOr() (node, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.is("or", "||") {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &logicalNode{and: false, left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *exprParser) parseAnd() (node, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for p.is("and", "&&") {
+		p.next()
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		left = &logicalNode{and: true, left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *exprParser) parseNot() (node, error) {
+	if p.is("not", "!") {
+		p.next()
+		operand, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return &notNode{operand: operand}, nil
+	}
+	return p.parseComparison()
+}
+
+func (p *exprParser) parseComparison() (node, error) {
+	left, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case p.is("==", "=", "!=", "<", "<=", ">", ">="):
+		op := p.next().text
+		if op == "=" {
+			op = "=="
+		}
+		right, err := p.parseOperand()
+		if err != nil {
+			return nil, err
+		}
+		return &compareNode{op: op, left: left, right: right}, nil
+	case p.is("=~", "!~"):
+		negate := p.next().text == "!~"
+		t := p.next()
+		if t.kind != tokString {
+			return nil, fmt.Errorf("regular expression at position %d must be a string", t.pos+1)
+		}
+		re, err := regexp.Compile(t.text)
+		if err != nil {
+			return nil, fmt.Errorf("regular expression at position %d: %s", t.pos+1, err)
+		}
+		return &matchNode{negate: negate, operand: left, re: re}, nil
+	case p.is("in"), p.is("not") && p.pos+1 < len(p.tokens) && strings.EqualFold(p.tokens[p.pos+1].text, "in"):
+		negate := p.is("not")
+		if negate {
+			p.next()
+		}
+		p.next()
+		return p.parseIn(left, negate)
+	}
+	return left, nil
+}
+
+// parseIn - Parses the range or list after the `in` operator.
+func (p *exprParser) parseIn(operand node, negate bool) (node, error) {
+	if p.is("(") {
+		p.next()
+		n := &listNode{negate: negate, operand: operand}
+		for {
+			e, err := p.parseOperand()
+			if err != nil {
+				return nil, err
+			}
+			n.list = append(n.list, e)
+			if !p.is(",") {
+				break
+			}
+			p.next()
+		}
+		return n, p.expect(")")
+	}
+	lo, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+	err = p.expect("..")
+	if err != nil {
+		return nil, err
+	}
+	hi, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+	return &rangeNode{negate: negate, operand: operand, lo: lo, hi: hi}, nil
+}
+
+// parseOperand - Parses the operands of comparisons, additions and subtractions.
+func (p *exprParser) parseOperand() (node, error) {
+	left, err := p.parseTerm()
+	if err != nil {
+		return nil, err
+	}
+	for p.is("+", "-") {
+		op := p.next().text
+		right, err := p.parseTerm()
+		if err != nil {
+			return nil, err
+		}
+		left = &arithmeticNode{op: op, left: left, right: right}
+	}
+	return left, nil
+}
+
+// parseTerm - Parses multiplications, divisions and modulos.
+func (p *exprParser) parseTerm() (node, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.is("*", "/", "%") {
+		op := p.next().text
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = &arithmeticNode{op: op, left: left, right: right}
+	}
+	return left, nil
+}
+
+// parseUnary - Parses negations and powers, powers are right associative and bind tighter than negations, -2^2 is -4.
+func (p *exprParser) parseUnary() (node, error) {
+	if p.is("-", "+") {
+		op := p.next().text
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		if op == "+" {
+			return operand, nil
+		}
+		return &arithmeticNode{op: "-", left: &literalNode{numberVal(0)}, right: operand}, nil
+	}
+	base, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+	if p.is("^") {
+		p.next()
+		exponent, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &arithmeticNode{op: "^", left: base, right: exponent}, nil
+	}
+	return base, nil
+}
+
+// parseCall - Parses the arguments of a function call.
+func (p *exprParser) parseCall(name token) (node, error) {
+	f, ok := functions[strings.ToLower(name.text)]
+	if !ok {
+		return nil, fmt.Errorf("unknown function '%s' at position %d", name.text, name.pos+1)
+	}
+	p.next()
+	n := &callNode{name: strings.ToLower(name.text), f: f}
+	for !p.is(")") {
+		if len(n.args) > 0 {
+			err := p.expect(",")
+			if err != nil {
+				return nil, err
+			}
+		}
+		arg, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		n.args = append(n.args, arg)
+	}
+	p.next()
+	if len(n.args) < f.min || f.max >= 0 && len(n.args) > f.max {
+		return nil, fmt.Errorf("wrong number of arguments for '%s' at position %d", name.text, name.pos+1)
+	}
+	return n, nil
+}
+
+func (p *exprParser) parsePrimary() (node, error) {
+	t := p.peek()
+	switch t.kind {
+	case tokNumber:
+		p.next()
+		x, err := strconv.ParseFloat(t.text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number '%s' at position %d", t.text, t.pos+1)
+		}
+		return &literalNode{numberVal(x)}, nil
+	case tokString:
+		p.next()
+		return &literalNode{value{kind: stringValue, str: t.text}}, nil
+	case tokColumn:
+		p.next()
+		return p.column(t.text), nil
+	case tokIdent:
+		switch strings.ToLower(t.text) {
+		case "true", "false":
+			p.next()
+			return &literalNode{boolVal(strings.EqualFold(t.text, "true"))}, nil
+		case "and", "or", "not", "in":
+			return nil, p.unexpected()
+		}
+		p.next()
+		if p.is("(") {
+			return p.parseCall(t)
+		}
+		return p.column(t.text), nil
+	case tokOp:
+		if t.text == "(" {
+			p.next()
+			n, err := p.parseOr()
+			if err != nil {
+				return nil, err
+			}
+			return n, p.expect(")")
+		}
+	}
+	return nil, p.unexpected()
+}
+
+// column - Returns a column node and records the column as referenced.
+func (p *exprParser) column(spec string) node {
+	found := false
+	for _, c := range p.expression.columns {
+		if c == spec {
+			found = true
+		}
+	}
+	if !found {
+		p.expression.columns = append(p.expression.columns, spec)
+	}
+	return &columnNode{spec: spec}
+}
@@ -0,0 +1,171 @@
+// This file is part of csv-analysis.
+//
+// Copyright (C) 2017  David Gamba Rios
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+package csvutil
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestWhere(t *testing.T) {
+	in := `id,status,region,latency,path,my col
+1,200,eu,120.5,/api/users,true
+2,500,us,80,/api/orders,false
+3,200,us,,/health,true
+4,404,eu,300,/api/users/4,false
+5,200,EU,1e3,/api/orders,true
+`
+	tests := []struct {
+		where    string
+		expected []string
+	}{
+		{`status == "200" and region == "eu"`, []string{"1"}},
+		{`status = 200 && region =~ "(?i)^eu$"`, []string{"1", "5"}},
+		{`status != 200`, []string{"2", "4"}},
+		{`latency < 100`, []string{"2"}},
+		{`latency != 80`, []string{"1", "3", "4", "5"}},
+		{`latency >= 120.5 or path !~ "^/api/"`, []string{"1", "3", "4", "5"}},
+		{`latency in 100..300`, []string{"1", "4"}},
+		{`latency not in 100..300`, []string{"2", "3", "5"}},
+		{`$2 in (404, 500) || not $1 <= 4`, []string{"2", "4", "5"}},
+		{`region in ("us", 'EU')`, []string{"2", "3", "5"}},
+		{"`my col` == true and !(id == 1)", []string{"3", "5"}},
+		{`latency`, []string{"1", "2", "4", "5"}},
+		{`id > -1 and id < 2e0`, []string{"1"}},
+		{`LATENCY > 1000`, []string{}},
+	}
+	for _, test := range tests {
+		e, err := ParseExpression(test.where)
+		if err != nil {
+			t.Fatalf("Unexpected error: %s\n", err)
+		}
+		cf := New()
+		cf.Where = e
+		r := cf.NewReader(strings.NewReader(in), "test.csv", "id")
+		ids := []string{}
+		for r.Next() {
+			ids = append(ids, r.Record()[0])
+		}
+		if err := r.Err(); err != nil {
+			t.Fatalf("Unexpected error: %s\n", err)
+		}
+		if !reflect.DeepEqual(ids, test.expected) {
+			t.Errorf("Wrong data for %s: %v != %v\n", test.where, ids, test.expected)
+		}
+	}
+
+	e, _ := ParseExpression(`missing == 1`)
+	cf := New()
+	cf.Where = e
+	r := cf.NewReader(strings.NewReader(in), "test.csv", "id")
+	if r.Next() || r.Err() == nil || r.Err().Error() != "test.csv: where: Column name error: 'missing' not found in header!" {
+		t.Errorf("Unexpected error: %v\n", r.Err())
+	}
+}
+
+func TestParseExpressionErrors(t *testing.T) {
+	tests := []struct {
+		in  string
+		err string
+	}{
+		{`status ==`, "expression 'status ==': unexpected end of expression"},
+		{`status == "200`, "expression 'status == \"200': unterminated string at position 11"},
+		{`(a == 1`, "expression '(a == 1': unexpected end of expression"},
+		{`a == 1 b`, "expression 'a == 1 b': unexpected 'b' at position 8"},
+		{`a =~ b`, "expression 'a =~ b': regular expression at position 6 must be a string"},
+		{`a =~ "("`, "expression 'a =~ \"(\"': regular expression at position 6: error parsing regexp: missing closing ): `(`"},
+		{`a in 1`, "expression 'a in 1': unexpected end of expression"},
+		{`a # 1`, "expression 'a # 1': unexpected '#' at position 3"},
+		{`$ == 1`, "expression '$ == 1': missing column index after '$' at position 1"},
+	}
+	for _, test := range tests {
+		_, err := ParseExpression(test.in)
+		if err == nil || err.Error() != test.err {
+			t.Errorf("Unexpected error: %v != %s\n", err, test.err)
+		}
+	}
+}
+
+func TestComputedColumns(t *testing.T) {
+	in := `start,end,bytes,duration
+2018-01-01T00:00:00Z,2018-01-01T00:00:01.5Z,3000,1.5
+2018-01-01T00:00:00Z,2018-01-01T00:01:00Z,100,0
+2018-01-01T00:00:00Z,,,2
+`
+	tests := []struct {
+		computed []string
+		columns  []string
+		where    string
+		expected [][]string
+	}{
+		{[]string{"throughput=bytes / duration", "elapsed=time(end) - time(start)"}, []string{"throughput", "elapsed"}, "",
+			[][]string{{"2000", "1.5"}, {"", "60"}, {"", ""}}},
+		{[]string{"a=-2^2 + 10 % 4 * 3", "b=round(log10(bytes)) + abs(-1) - min(duration, 1, 2)", "c=a + b", "d=duration > 1"}, []string{"a", "b", "c", "d"}, "",
+			[][]string{{"2", "3", "5", "true"}, {"2", "3", "5", "false"}, {"2", "", "", "true"}}},
+		{[]string{"kb=bytes / 1000"}, []string{"kb"}, "kb >= 1 or sqrt(duration) > 1.4",
+			[][]string{{"3"}, {""}}},
+		{[]string{"day=time(start, \"2006-01-02T15:04:05Z\") / 86400"}, []string{"day"}, "",
+			[][]string{{"17532"}, {"17532"}, {"17532"}}},
+	}
+	for _, test := range tests {
+		cf := New()
+		for _, c := range test.computed {
+			computed, err := ParseComputedColumn(c)
+			if err != nil {
+				t.Fatalf("Unexpected error: %s\n", err)
+			}
+			cf.Computed = append(cf.Computed, computed)
+		}
+		if test.where != "" {
+			cf.Where, _ = ParseExpression(test.where)
+		}
+		records, err := readColumns(cf.NewReader(strings.NewReader(in), "test.csv", test.columns...))
+		if err != nil {
+			t.Fatalf("Unexpected error: %s\n", err)
+		}
+		var rows [][]string
+		for i := range records[0] {
+			var row []string
+			for _, c := range records {
+				row = append(row, c[i])
+			}
+			rows = append(rows, row)
+		}
+		if !reflect.DeepEqual(rows, test.expected) {
+			t.Errorf("Wrong data for %v: %v != %v\n", test.computed, rows, test.expected)
+		}
+	}
+
+	cf := New()
+	cf.NoHeader = true
+	c, _ := ParseComputedColumn("sum = $1 + $2")
+	cf.Computed = []ComputedColumn{c}
+	r := cf.NewReader(strings.NewReader("1,2\n3,4,5\n"), "test.csv", "sum", "2")
+	var records [][]string
+	for r.Next() {
+		records = append(records, r.Record())
+	}
+	if err := r.Err(); err != nil {
+		t.Fatalf("Unexpected error: %s\n", err)
+	}
+	if !reflect.DeepEqual(records, [][]string{{"3", "2"}, {"7", "4"}}) {
+		t.Errorf("Wrong data: %v\n", records)
+	}
+
+	for _, test := range []struct{ in, err string }{
+		{"x", "computed column 'x' must be given as <name>=<expression>"},
+		{"x=foo(1)", "computed column 'x': expression 'foo(1)': unknown function 'foo' at position 1"},
+		{"x=pow(1)", "computed column 'x': expression 'pow(1)': wrong number of arguments for 'pow' at position 1"},
+	} {
+		_, err := ParseComputedColumn(test.in)
+		if err == nil || err.Error() != test.err {
+			t.Errorf("Unexpected error: %v != %s\n", err, test.err)
+		}
+	}
+}
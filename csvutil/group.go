@@ -0,0 +1,62 @@
+// This file is part of csv-analysis.
+//
+// Copyright (C) 2017  David Gamba Rios
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package csvutil
+
+import (
+	"math"
+	"strings"
+)
+
+// Group - Values of a column for a distinct combination of values of the key columns.
+type Group struct {
+	Keys   []string
+	Values []float64
+}
+
+// GroupFloat64ColumnByName - Reads the column as floats grouped by the values of the key columns, in order of first appearance.
+// Columns can be given as 1-based indexes or as header names, see resolveColumn, the "source" column groups by file.
+// Key values are compared after trimming surrounding white space.
+// Values that are empty, unparsable or zero when FilterZero is set are dropped, unparsable values are added to the Report.
+func (cf *CSVFiles) GroupFloat64ColumnByName(keys []string, column string) ([]*Group, error) {
+	var groups []*Group
+	index := make(map[string]*Group)
+	columns := append(append([]string{}, keys...), column)
+	keyIndexes := make([]int, len(keys))
+	for i := range keys {
+		keyIndexes[i] = i
+	}
+	err := cf.eachReader(columns, func(r *Reader) error {
+		for r.Next() {
+			record := r.Record()
+			x, err := r.float64Field(len(keys), record[len(keys)])
+			if err != nil {
+				return err
+			}
+			if math.IsNaN(x) {
+				continue
+			}
+			key := joinKey(record, keyIndexes)
+			g, ok := index[key]
+			if !ok {
+				g = &Group{Keys: make([]string, len(keys))}
+				for i := range keys {
+					g.Keys[i] = strings.TrimSpace(record[i])
+				}
+				index[key] = g
+				groups = append(groups, g)
+			}
+			g.Values = append(g.Values, x)
+		}
+		return r.Err()
+	})
+	if err != nil {
+		return nil, err
+	}
+	return groups, nil
+}
@@ -0,0 +1,52 @@
+// This file is part of csv-analysis.
+//
+// Copyright (C) 2017  David Gamba Rios
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+package csvutil
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestGroupFloat64ColumnByName(t *testing.T) {
+	file := tempFile(t, `host,path,latency
+a,/x,10
+b,/x,20
+ a ,/y,30
+a,/x,
+b,/x,x
+a,/x,50
+`)
+	cf := New(file)
+	groups, err := cf.GroupFloat64ColumnByName([]string{"host"}, "latency")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s\n", err)
+	}
+	expected := []*Group{
+		&Group{Keys: []string{"a"}, Values: []float64{10, 30, 50}},
+		&Group{Keys: []string{"b"}, Values: []float64{20}},
+	}
+	if !reflect.DeepEqual(groups, expected) {
+		t.Errorf("Wrong groups: %v != %v\n", groups, expected)
+	}
+	if cf.Report.Count() != 1 {
+		t.Errorf("Wrong error count: %d != 1\n", cf.Report.Count())
+	}
+
+	groups, err = cf.GroupFloat64ColumnByName([]string{"host", "path"}, "3")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s\n", err)
+	}
+	expected = []*Group{
+		&Group{Keys: []string{"a", "/x"}, Values: []float64{10, 50}},
+		&Group{Keys: []string{"b", "/x"}, Values: []float64{20}},
+		&Group{Keys: []string{"a", "/y"}, Values: []float64{30}},
+	}
+	if !reflect.DeepEqual(groups, expected) {
+		t.Errorf("Wrong groups: %v != %v\n", groups, expected)
+	}
+}
@@ -0,0 +1,169 @@
+// This file is part of csv-analysis.
+//
+// Copyright (C) 2017  David Gamba Rios
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package csvutil
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// HeaderPolicy - How to handle files with a header that differs from the header of the first file.
+type HeaderPolicy int
+
+const (
+	// HeaderWarn - Add a warning with the differences to the Report and read the columns as requested.
+	HeaderWarn HeaderPolicy = iota
+	// HeaderFail - Abort with the differences as error.
+	HeaderFail
+	// HeaderAlign - Realign the columns by name to the header of the first file.
+	// Columns missing from the file are empty and columns not in the first file are dropped, both are reported as warnings.
+	HeaderAlign
+	// HeaderIgnore - Don't compare the headers.
+	HeaderIgnore
+)
+
+var headerPolicyNames = map[HeaderPolicy]string{
+	HeaderWarn:   "warn",
+	HeaderFail:   "fail",
+	HeaderAlign:  "align",
+	HeaderIgnore: "ignore",
+}
+
+func (p HeaderPolicy) String() string {
+	return headerPolicyNames[p]
+}
+
+// ParseHeaderPolicy - Returns the HeaderPolicy for the given name: warn, fail, align or ignore.
+func ParseHeaderPolicy(name string) (HeaderPolicy, error) {
+	for p, n := range headerPolicyNames {
+		if n == name {
+			return p, nil
+		}
+	}
+	return HeaderWarn, fmt.Errorf("unknown header policy '%s'", name)
+}
+
+// checkHeader - Compares the header of the reader with the header of the first file read and applies the Headers policy.
+// The first header read becomes the reference, a warning is added when it looks like data instead of a header.
+func (r *Reader) checkHeader() error {
+	cf := r.cf
+	if cf.Headers == HeaderIgnore || r.header == nil {
+		return nil
+	}
+	if cf.firstHeader == nil {
+		cf.firstHeader, cf.firstFile = r.header, r.name
+		if looksLikeData(r.header) {
+			cf.addWarning("%s: header row %v looks like data, use NoHeader if the file has no header", r.name, r.header)
+		}
+		return nil
+	}
+	diff := headerDiff(cf.firstHeader, r.header)
+	if diff == "" {
+		return nil
+	}
+	switch cf.Headers {
+	case HeaderFail:
+		return fmt.Errorf("header differs from %s: %s", cf.firstFile, diff)
+	case HeaderAlign:
+		r.align = alignHeader(cf.firstHeader, r.header)
+		r.header = append([]string{}, cf.firstHeader...)
+		cf.addWarning("%s: header realigned to %s: %s", r.name, cf.firstFile, diff)
+	default:
+		cf.addWarning("%s: header differs from %s: %s", r.name, cf.firstFile, diff)
+	}
+	return nil
+}
+
+// realign - Returns the fields of the record in the order of the header of the first file.
+func (r *Reader) realign(record []string) []string {
+	aligned := make([]string, len(r.align))
+	for i, j := range r.align {
+		if j >= 0 && j < len(record) {
+			aligned[i] = record[j]
+		}
+	}
+	return aligned
+}
+
+// headerKey - Returns the name used to compare header columns, ignoring surrounding white space.
+func headerKey(name string) string {
+	return strings.TrimSpace(name)
+}
+
+// headerPositions - Returns the 0-based positions of each name in the header.
+func headerPositions(header []string) map[string][]int {
+	positions := make(map[string][]int)
+	for i, name := range header {
+		key := headerKey(name)
+		positions[key] = append(positions[key], i)
+	}
+	return positions
+}
+
+// alignHeader - Returns, for each column of the reference, its 0-based index in the header, -1 when it is missing.
+// Repeated names are matched in order.
+func alignHeader(reference, header []string) []int {
+	positions := headerPositions(header)
+	align := make([]int, len(reference))
+	for i, name := range reference {
+		key := headerKey(name)
+		align[i] = -1
+		if p := positions[key]; len(p) > 0 {
+			align[i], positions[key] = p[0], p[1:]
+		}
+	}
+	return align
+}
+
+// headerDiff - Describes the columns of the header that are missing, extra or moved compared to the reference.
+// Returns an empty string when the headers are the same.
+func headerDiff(reference, header []string) string {
+	var missing, moved, extra []string
+	align := alignHeader(reference, header)
+	found := make([]bool, len(header))
+	for i, j := range align {
+		if j < 0 {
+			missing = append(missing, fmt.Sprintf("'%s' (%d)", reference[i], i+1))
+			continue
+		}
+		found[j] = true
+		if i != j {
+			moved = append(moved, fmt.Sprintf("'%s' (%d -> %d)", reference[i], i+1, j+1))
+		}
+	}
+	for j, name := range header {
+		if !found[j] {
+			extra = append(extra, fmt.Sprintf("'%s' (%d)", name, j+1))
+		}
+	}
+	var diff []string
+	for _, d := range []struct {
+		name    string
+		columns []string
+	}{{"missing", missing}, {"extra", extra}, {"moved", moved}} {
+		if len(d.columns) > 0 {
+			diff = append(diff, d.name+" "+strings.Join(d.columns, ", "))
+		}
+	}
+	return strings.Join(diff, "; ")
+}
+
+// looksLikeData - Indicates if every field of the header is a number.
+func looksLikeData(header []string) bool {
+	if len(header) == 0 {
+		return false
+	}
+	for _, name := range header {
+		if _, err := strconv.ParseFloat(strings.TrimSpace(name), 64); err != nil {
+			return false
+		}
+	}
+	return true
+}
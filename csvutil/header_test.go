@@ -0,0 +1,89 @@
+// This file is part of csv-analysis.
+//
+// Copyright (C) 2017  David Gamba Rios
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+package csvutil
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestHeaderDiff(t *testing.T) {
+	tests := []struct {
+		reference []string
+		header    []string
+		expected  string
+	}{
+		{[]string{"a", "b", "c"}, []string{"a", " b", "c"}, ""},
+		{[]string{"a", "b", "c"}, []string{"a", "c", "b"}, "moved 'b' (2 -> 3), 'c' (3 -> 2)"},
+		{[]string{"a", "b", "c"}, []string{"a", "b", "d"}, "missing 'c' (3); extra 'd' (3)"},
+		{[]string{"a", "b"}, []string{"b", "a", "c"}, "extra 'c' (3); moved 'a' (1 -> 2), 'b' (2 -> 1)"},
+		{[]string{"x", "x"}, []string{"x"}, "missing 'x' (2)"},
+	}
+	for _, test := range tests {
+		diff := headerDiff(test.reference, test.header)
+		if diff != test.expected {
+			t.Errorf("Wrong diff for %v: %s != %s\n", test.header, diff, test.expected)
+		}
+	}
+}
+
+func TestHeaderPolicy(t *testing.T) {
+	var files []string
+	for _, data := range []string{"a,b,c\n1,2,3\n", "c,a,d\n6,4,7\n"} {
+		files = append(files, tempFile(t, data))
+	}
+	tests := []struct {
+		policy   HeaderPolicy
+		expected [][]string
+		warnings []string
+	}{
+		{HeaderIgnore, [][]string{{"1", "6"}, {"3", "7"}}, nil},
+		{HeaderWarn, [][]string{{"1", "6"}, {"3", "7"}},
+			[]string{files[1] + ": header differs from " + files[0] + ": missing 'b' (2); extra 'd' (3); moved 'a' (1 -> 2), 'c' (3 -> 1)"}},
+		{HeaderAlign, [][]string{{"1", "4"}, {"3", "6"}},
+			[]string{files[1] + ": header realigned to " + files[0] + ": missing 'b' (2); extra 'd' (3); moved 'a' (1 -> 2), 'c' (3 -> 1)"}},
+	}
+	for _, test := range tests {
+		cf := New(files...)
+		cf.Headers = test.policy
+		columns, err := cf.GetCSVColumnsByName("1", "3")
+		if err != nil {
+			t.Fatalf("Unexpected error: %s\n", err)
+		}
+		if !reflect.DeepEqual(columns, test.expected) {
+			t.Errorf("Wrong data for %s: %v != %v\n", test.policy, columns, test.expected)
+		}
+		if !reflect.DeepEqual(cf.Report.Warnings, test.warnings) {
+			t.Errorf("Wrong warnings for %s: %v != %v\n", test.policy, cf.Report.Warnings, test.warnings)
+		}
+	}
+
+	cf := New(files...)
+	cf.Headers = HeaderFail
+	_, err := cf.GetCSVColumnsByName("a")
+	expected := files[1] + ": header differs from " + files[0] + ": missing 'b' (2); extra 'd' (3); moved 'a' (1 -> 2), 'c' (3 -> 1)"
+	if err == nil || err.Error() != expected {
+		t.Errorf("Unexpected error: %v\n", err)
+	}
+}
+
+func TestParseHeaderPolicy(t *testing.T) {
+	for _, name := range []string{"warn", "fail", "align", "ignore"} {
+		p, err := ParseHeaderPolicy(name)
+		if err != nil {
+			t.Fatalf("Unexpected error: %s\n", err)
+		}
+		if p.String() != name {
+			t.Errorf("Wrong policy: %s != %s\n", p, name)
+		}
+	}
+	_, err := ParseHeaderPolicy("strict")
+	if err == nil || err.Error() != "unknown header policy 'strict'" {
+		t.Errorf("Unexpected error: %v\n", err)
+	}
+}
@@ -0,0 +1,346 @@
+// This file is part of csv-analysis.
+//
+// Copyright (C) 2017  David Gamba Rios
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package csvutil
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// JoinType - How records without a match in the other files are handled.
+type JoinType int
+
+// Join types.
+const (
+	// InnerJoin - Only records with a match in every file.
+	InnerJoin JoinType = iota
+	// LeftJoin - Every record of the first file, with empty values for the files without a match.
+	LeftJoin
+	// OuterJoin - Every record of every file, with empty values for the files without a match.
+	OuterJoin
+)
+
+var joinTypeNames = []string{"inner", "left", "outer"}
+
+func (t JoinType) String() string {
+	return joinTypeNames[t]
+}
+
+// ParseJoinType - Returns the join type with the given name: inner, left or outer.
+func ParseJoinType(name string) (JoinType, error) {
+	for i, n := range joinTypeNames {
+		if n == name {
+			return JoinType(i), nil
+		}
+	}
+	return 0, fmt.Errorf("unknown join type '%s'", name)
+}
+
+// Join - Settings to join the files side by side on key columns, instead of concatenating them.
+//
+// The files are joined in order, the first with the second, the result with the third and so on.
+// The joined records have the columns of the first file followed by the columns of the other files, except for their key columns.
+// Column names already in the header get the file number as suffix, "latency_2" for the second file.
+type Join struct {
+	Type JoinType
+	// Key columns, by index or name, resolved against the header of each file.
+	Keys []string
+	// Match the last key to the nearest value, within Tolerance, instead of an exact match.
+	// The values are parsed as numbers with the column parser, or as timestamps into Unix seconds, see TimestampLayouts.
+	AsOf bool
+	// Maximum distance between as-of matches, 0 means no limit.
+	Tolerance float64
+}
+
+// table - Records of a file, or of joined files, read into memory.
+type table struct {
+	header  []string
+	records [][]string
+	lines   []int
+	width   int
+	keys    []int // 0-based indexes of the key columns.
+	// Parse functions of Parsers by 1-based column index, see resolveParsers.
+	parsers map[int]func(string) (float64, error)
+}
+
+// tableSource - Source over the records of a table.
+type tableSource struct {
+	t    *table
+	next int
+}
+
+// Header - Returns the header of the table.
+func (s *tableSource) Header() []string {
+	return s.t.header
+}
+
+// Read - Returns the next record, io.EOF when there are no more records.
+func (s *tableSource) Read() ([]string, error) {
+	if s.next >= len(s.t.records) {
+		return nil, io.EOF
+	}
+	s.next++
+	return s.t.records[s.next-1], nil
+}
+
+// Line - Returns the line number of the last record read in the file it comes from.
+func (s *tableSource) Line() int {
+	return s.t.lines[s.next-1]
+}
+
+// join - Reads and joins the files.
+func (cf *CSVFiles) join() (*tableSource, error) {
+	if len(cf.Join.Keys) == 0 {
+		return nil, fmt.Errorf("join requires at least one key column")
+	}
+	var result *table
+	for i, file := range cf.Files {
+		t, err := cf.readTable(file)
+		if err != nil {
+			return nil, err
+		}
+		if result == nil {
+			result = t
+			continue
+		}
+		result = cf.joinTables(result, t, i+1)
+	}
+	if result == nil {
+		result = &table{}
+	}
+	return &tableSource{t: result}, nil
+}
+
+// readTable - Reads the file into memory and resolves the key columns.
+func (cf *CSVFiles) readTable(file string) (*table, error) {
+	fh, err := cf.open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer fh.Close()
+	return cf.newTable(fh, file)
+}
+
+// newTable - Reads the records from `reader` into memory and resolves the key columns.
+// NDJSON tables get every field path found in the file, not only the ones in the read-ahead rows.
+func (cf *CSVFiles) newTable(reader io.Reader, file string) (*table, error) {
+	src, err := cf.newSource(reader)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", file, err)
+	}
+	t := &table{}
+	ns, isNDJSON := src.(*ndjsonSource)
+	if isNDJSON {
+		ns.addPaths(cf.Join.Keys...)
+		ns.grow = true
+	}
+	if hs, ok := src.(headerSource); ok {
+		t.header = hs.Header()
+	} else if !cf.NoHeader {
+		t.header, err = src.Read()
+		if err != nil && err != io.EOF {
+			return nil, fmt.Errorf("%s: %s", file, err)
+		}
+	}
+	t.width = len(t.header)
+	for {
+		record, err := src.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", file, err)
+		}
+		t.records = append(t.records, record)
+		t.lines = append(t.lines, src.Line())
+		if len(record) > t.width {
+			t.width = len(record)
+		}
+	}
+	if isNDJSON {
+		t.header = ns.Header()
+		t.width = len(t.header)
+	}
+	indexes, err := resolveColumns(t.header, cf.Join.Keys...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: join: %s", file, err)
+	}
+	for _, index := range indexes {
+		if index <= 0 {
+			return nil, fmt.Errorf("%s: join: Column index error: %d <= 0!", file, index)
+		}
+		t.keys = append(t.keys, index-1)
+	}
+	t.parsers = cf.resolveParsers(t.header)
+	return t, nil
+}
+
+// joinTables - Joins the records of the right table, the n-th file, to the left table.
+func (cf *CSVFiles) joinTables(left, right *table, n int) *table {
+	j := cf.Join
+	isKey := make(map[int]bool)
+	for _, k := range right.keys {
+		isKey[k] = true
+	}
+	var columns []int
+	for i := 0; i < right.width; i++ {
+		if !isKey[i] {
+			columns = append(columns, i)
+		}
+	}
+	result := &table{keys: left.keys, width: left.width + len(columns)}
+	if left.header != nil || right.header != nil {
+		result.header = make([]string, left.width, result.width)
+		copy(result.header, left.header)
+		names := make(map[string]bool)
+		for _, name := range result.header {
+			names[name] = true
+		}
+		for _, i := range columns {
+			name := ""
+			if i < len(right.header) {
+				name = right.header[i]
+			}
+			if names[name] {
+				name = fmt.Sprintf("%s_%d", name, n)
+			}
+			names[name] = true
+			result.header = append(result.header, name)
+		}
+	}
+	result.parsers = cf.resolveParsers(result.header)
+
+	exactKeys := len(j.Keys)
+	if j.AsOf {
+		exactKeys--
+	}
+	asOf := func(t *table, record []string) (float64, bool) {
+		return cf.asOfValue(cf.columnParser(t.parsers, t.keys[exactKeys]+1), field(record, t.keys[exactKeys]))
+	}
+	// Right records grouped by their exact keys and, for as-of joins, sorted by their as-of key.
+	groups := make(map[string][]int)
+	values := make([]float64, len(right.records))
+	for i, record := range right.records {
+		if j.AsOf {
+			var ok bool
+			values[i], ok = asOf(right, record)
+			if !ok {
+				continue
+			}
+		}
+		key := joinKey(record, right.keys[:exactKeys])
+		groups[key] = append(groups[key], i)
+	}
+	if j.AsOf {
+		for _, group := range groups {
+			sort.SliceStable(group, func(a, b int) bool { return values[group[a]] < values[group[b]] })
+		}
+	}
+
+	add := func(l, r []string, line int) {
+		record := make([]string, left.width, result.width)
+		copy(record, l)
+		for _, i := range columns {
+			record = append(record, field(r, i))
+		}
+		result.records = append(result.records, record)
+		result.lines = append(result.lines, line)
+	}
+	matched := make([]bool, len(right.records))
+	for i, record := range left.records {
+		key := joinKey(record, left.keys[:exactKeys])
+		group := groups[key]
+		if j.AsOf {
+			group = nil
+			if x, ok := asOf(left, record); ok {
+				group = nearest(groups[key], values, x, j.Tolerance)
+			}
+		}
+		for _, m := range group {
+			add(record, right.records[m], left.lines[i])
+			matched[m] = true
+		}
+		if len(group) == 0 && j.Type != InnerJoin {
+			add(record, nil, left.lines[i])
+		}
+	}
+	if j.Type == OuterJoin {
+		for i, record := range right.records {
+			if matched[i] {
+				continue
+			}
+			l := make([]string, left.width)
+			for k, index := range left.keys {
+				if index < len(l) {
+					l[index] = field(record, right.keys[k])
+				}
+			}
+			add(l, record, right.lines[i])
+		}
+	}
+	return result
+}
+
+// nearest - Returns the element of the group, sorted by values, with the value nearest to x within the tolerance.
+// Ties go to the smaller value.
+func nearest(group []int, values []float64, x, tolerance float64) []int {
+	i := sort.Search(len(group), func(i int) bool { return values[group[i]] >= x })
+	best, distance := -1, math.Inf(1)
+	for _, c := range []int{i - 1, i} {
+		if c < 0 || c >= len(group) {
+			continue
+		}
+		if d := math.Abs(values[group[c]] - x); d < distance {
+			best, distance = group[c], d
+		}
+	}
+	if best < 0 || tolerance > 0 && distance > tolerance {
+		return nil
+	}
+	return []int{best}
+}
+
+// asOfValue - Parses the value of an as-of key as a number, or as a timestamp into Unix seconds.
+func (cf *CSVFiles) asOfValue(parse func(string) (float64, error), s string) (float64, bool) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, false
+	}
+	if x, err := parse(s); err == nil && !math.IsNaN(x) {
+		return x, true
+	}
+	for _, layout := range TimestampLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return float64(t.UnixNano()) / 1e9, true
+		}
+	}
+	return 0, false
+}
+
+// joinKey - Returns the values of the key columns as a single string.
+func joinKey(record []string, keys []int) string {
+	values := make([]string, len(keys))
+	for i, k := range keys {
+		values[i] = strconv.Quote(strings.TrimSpace(field(record, k)))
+	}
+	return strings.Join(values, ",")
+}
+
+// field - Returns the field at the 0-based index, empty when the record is short.
+func field(record []string, i int) string {
+	if i < len(record) {
+		return record[i]
+	}
+	return ""
+}
@@ -0,0 +1,160 @@
+// This file is part of csv-analysis.
+//
+// Copyright (C) 2017  David Gamba Rios
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+package csvutil
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestJoin(t *testing.T) {
+	load := `host,time,load
+a,2018-01-01T00:00:00Z,1
+a,2018-01-01T00:01:00Z,2
+b,2018-01-01T00:00:00Z,3
+c,2018-01-01T00:00:00Z,4
+`
+	latency := `time,host,latency,load
+2018-01-01T00:00:00Z,a,10,x
+2018-01-01T00:01:02Z,a,20,y
+2018-01-01T00:00:30Z,b,30,z
+2018-01-01T00:00:00Z,d,40,w
+`
+	tests := []struct {
+		join     Join
+		header   []string
+		expected [][]string
+		lines    []int
+	}{
+		{Join{Type: InnerJoin, Keys: []string{"host", "time"}},
+			[]string{"host", "time", "load", "latency", "load_2"},
+			[][]string{
+				{"a", "2018-01-01T00:00:00Z", "1", "10", "x"},
+			}, []int{2}},
+		{Join{Type: LeftJoin, Keys: []string{"host", "time"}},
+			[]string{"host", "time", "load", "latency", "load_2"},
+			[][]string{
+				{"a", "2018-01-01T00:00:00Z", "1", "10", "x"},
+				{"a", "2018-01-01T00:01:00Z", "2", "", ""},
+				{"b", "2018-01-01T00:00:00Z", "3", "", ""},
+				{"c", "2018-01-01T00:00:00Z", "4", "", ""},
+			}, []int{2, 3, 4, 5}},
+		{Join{Type: OuterJoin, Keys: []string{"host", "time"}},
+			[]string{"host", "time", "load", "latency", "load_2"},
+			[][]string{
+				{"a", "2018-01-01T00:00:00Z", "1", "10", "x"},
+				{"a", "2018-01-01T00:01:00Z", "2", "", ""},
+				{"b", "2018-01-01T00:00:00Z", "3", "", ""},
+				{"c", "2018-01-01T00:00:00Z", "4", "", ""},
+				{"a", "2018-01-01T00:01:02Z", "", "20", "y"},
+				{"b", "2018-01-01T00:00:30Z", "", "30", "z"},
+				{"d", "2018-01-01T00:00:00Z", "", "40", "w"},
+			}, []int{2, 3, 4, 5, 3, 4, 5}},
+		{Join{Type: InnerJoin, Keys: []string{"host", "time"}, AsOf: true, Tolerance: 5},
+			[]string{"host", "time", "load", "latency", "load_2"},
+			[][]string{
+				{"a", "2018-01-01T00:00:00Z", "1", "10", "x"},
+				{"a", "2018-01-01T00:01:00Z", "2", "20", "y"},
+			}, []int{2, 3}},
+		{Join{Type: LeftJoin, Keys: []string{"host", "time"}, AsOf: true},
+			[]string{"host", "time", "load", "latency", "load_2"},
+			[][]string{
+				{"a", "2018-01-01T00:00:00Z", "1", "10", "x"},
+				{"a", "2018-01-01T00:01:00Z", "2", "20", "y"},
+				{"b", "2018-01-01T00:00:00Z", "3", "30", "z"},
+				{"c", "2018-01-01T00:00:00Z", "4", "", ""},
+			}, []int{2, 3, 4, 5}},
+	}
+	for _, test := range tests {
+		cf := New()
+		j := test.join
+		cf.Join = &j
+		left, err := cf.newTable(strings.NewReader(load), "load.csv")
+		if err != nil {
+			t.Fatalf("Unexpected error: %s\n", err)
+		}
+		right, err := cf.newTable(strings.NewReader(latency), "latency.csv")
+		if err != nil {
+			t.Fatalf("Unexpected error: %s\n", err)
+		}
+		result := cf.joinTables(left, right, 2)
+		if !reflect.DeepEqual(result.header, test.header) {
+			t.Errorf("Wrong header: %v != %v\n", result.header, test.header)
+		}
+		if !reflect.DeepEqual(result.records, test.expected) {
+			t.Errorf("Wrong data for %v: %v != %v\n", test.join, result.records, test.expected)
+		}
+		if !reflect.DeepEqual(result.lines, test.lines) {
+			t.Errorf("Wrong lines: %v != %v\n", result.lines, test.lines)
+		}
+
+		// The joined records are read like any other source.
+		r := &Reader{cf: cf, src: &tableSource{t: result}, name: "joined", columns: []string{"load", "latency"}}
+		n := 0
+		for r.Next() {
+			n++
+		}
+		if err := r.Err(); err != nil || n != len(test.expected) {
+			t.Errorf("Unexpected error: %v, records: %d\n", err, n)
+		}
+	}
+
+	cf := New()
+	cf.Join = &Join{Keys: []string{"id"}}
+	_, err := cf.newTable(strings.NewReader(load), "load.csv")
+	if err == nil || err.Error() != "load.csv: join: Column name error: 'id' not found in header!" {
+		t.Errorf("Unexpected error: %v\n", err)
+	}
+}
+
+func TestJoinNDJSONLateFields(t *testing.T) {
+	// The key and the value fields first appear after the read-ahead rows.
+	var events strings.Builder
+	for i := 0; i < sniffRows; i++ {
+		events.WriteString("{\"seq\": 1}\n")
+	}
+	events.WriteString("{\"seq\": 2, \"host\": \"a\", \"load\": 5}\n")
+	hosts := "host,region\na,eu\n"
+
+	cf := New()
+	cf.Join = &Join{Keys: []string{"host"}}
+	left, err := cf.newTable(strings.NewReader(events.String()), "events.ndjson")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s\n", err)
+	}
+	right, err := cf.newTable(strings.NewReader(hosts), "hosts.csv")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s\n", err)
+	}
+	result := cf.joinTables(left, right, 2)
+	header := []string{"seq", "host", "load", "region"}
+	if !reflect.DeepEqual(result.header, header) {
+		t.Errorf("Wrong header: %v != %v\n", result.header, header)
+	}
+	expected := [][]string{{"2", "a", "5", "eu"}}
+	if !reflect.DeepEqual(result.records, expected) {
+		t.Errorf("Wrong data: %v != %v\n", result.records, expected)
+	}
+}
+
+func TestParseJoinType(t *testing.T) {
+	for _, name := range []string{"inner", "left", "outer"} {
+		j, err := ParseJoinType(name)
+		if err != nil {
+			t.Fatalf("Unexpected error: %s\n", err)
+		}
+		if j.String() != name {
+			t.Errorf("Wrong join type: %s != %s\n", j, name)
+		}
+	}
+	_, err := ParseJoinType("cross")
+	if err == nil || err.Error() != "unknown join type 'cross'" {
+		t.Errorf("Unexpected error: %v\n", err)
+	}
+}
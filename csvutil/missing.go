@@ -0,0 +1,109 @@
+// This file is part of csv-analysis.
+//
+// Copyright (C) 2017  David Gamba Rios
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package csvutil
+
+import (
+	"fmt"
+	"math"
+)
+
+// MissingPolicy - How to handle records with missing values.
+type MissingPolicy int
+
+const (
+	// DropRow - Drop the whole record.
+	DropRow MissingPolicy = iota
+	// FillNaN - Keep the record with NaN as the missing value.
+	FillNaN
+	// FillPrevious - Use the previous valid value of the column.
+	// Records without a previous valid value are dropped.
+	FillPrevious
+	// Interpolate - Linearly interpolate between the previous and next valid values of the column.
+	// Records without both a previous and a next valid value are dropped.
+	Interpolate
+)
+
+var missingPolicyNames = map[MissingPolicy]string{
+	DropRow:      "drop",
+	FillNaN:      "nan",
+	FillPrevious: "previous",
+	Interpolate:  "interpolate",
+}
+
+func (p MissingPolicy) String() string {
+	return missingPolicyNames[p]
+}
+
+// ParseMissingPolicy - Returns the MissingPolicy for the given name: drop, nan, previous or interpolate.
+func ParseMissingPolicy(name string) (MissingPolicy, error) {
+	for p, n := range missingPolicyNames {
+		if n == name {
+			return p, nil
+		}
+	}
+	return DropRow, fmt.Errorf("unknown missing value policy '%s'", name)
+}
+
+// applyMissingPolicy - Given rows where missing values are NaN, applies the policy.
+func applyMissingPolicy(rows [][]float64, p MissingPolicy) [][]float64 {
+	n := 0
+	if len(rows) > 0 {
+		n = len(rows[0])
+	}
+	switch p {
+	case FillNaN:
+		return rows
+	case FillPrevious:
+		for j := 0; j < n; j++ {
+			previous := math.NaN()
+			for _, row := range rows {
+				if math.IsNaN(row[j]) {
+					row[j] = previous
+				} else {
+					previous = row[j]
+				}
+			}
+		}
+	case Interpolate:
+		for j := 0; j < n; j++ {
+			last := -1
+			for i, row := range rows {
+				if math.IsNaN(row[j]) {
+					continue
+				}
+				if last >= 0 && i-last > 1 {
+					step := (row[j] - rows[last][j]) / float64(i-last)
+					for k := last + 1; k < i; k++ {
+						rows[k][j] = rows[last][j] + step*float64(k-last)
+					}
+				}
+				last = i
+			}
+		}
+	}
+	return dropNaNRows(rows)
+}
+
+// dropNaNRows - Returns the rows that don't have NaN values.
+func dropNaNRows(rows [][]float64) [][]float64 {
+	var result [][]float64
+	for _, row := range rows {
+		keep := true
+		for _, e := range row {
+			if math.IsNaN(e) {
+				keep = false
+				break
+			}
+		}
+		if keep {
+			result = append(result, row)
+		}
+	}
+	return result
+}
@@ -0,0 +1,59 @@
+// This file is part of csv-analysis.
+//
+// Copyright (C) 2017  David Gamba Rios
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+package csvutil
+
+import (
+	"fmt"
+	"math"
+	"testing"
+)
+
+func TestApplyMissingPolicy(t *testing.T) {
+	nan := math.NaN()
+	in := func() [][]float64 {
+		return [][]float64{
+			{nan, 10},
+			{1, 20},
+			{2, nan},
+			{nan, nan},
+			{5, 50},
+			{6, nan},
+		}
+	}
+	tests := []struct {
+		policy   MissingPolicy
+		expected [][]float64
+	}{
+		{DropRow, [][]float64{{1, 20}, {5, 50}}},
+		{FillNaN, [][]float64{{nan, 10}, {1, 20}, {2, nan}, {nan, nan}, {5, 50}, {6, nan}}},
+		{FillPrevious, [][]float64{{1, 20}, {2, 20}, {2, 20}, {5, 50}, {6, 50}}},
+		{Interpolate, [][]float64{{1, 20}, {2, 30}, {3.5, 40}, {5, 50}}},
+	}
+	for _, test := range tests {
+		rows := applyMissingPolicy(in(), test.policy)
+		if fmt.Sprint(rows) != fmt.Sprint(test.expected) {
+			t.Errorf("Wrong data for %s: %v != %v\n", test.policy, rows, test.expected)
+		}
+	}
+}
+
+func TestParseMissingPolicy(t *testing.T) {
+	for _, name := range []string{"drop", "nan", "previous", "interpolate"} {
+		p, err := ParseMissingPolicy(name)
+		if err != nil {
+			t.Fatalf("Unexpected error: %s\n", err)
+		}
+		if p.String() != name {
+			t.Errorf("Wrong policy: %s != %s\n", p, name)
+		}
+	}
+	_, err := ParseMissingPolicy("zero")
+	if err == nil || err.Error() != "unknown missing value policy 'zero'" {
+		t.Errorf("Unexpected error: %v\n", err)
+	}
+}
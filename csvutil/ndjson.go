@@ -0,0 +1,215 @@
+// This file is part of csv-analysis.
+//
+// Copyright (C) 2017  David Gamba Rios
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package csvutil
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// ndjsonSource - Reads records from newline delimited JSON, one object per line.
+// Nested objects and arrays are flattened into dotted field paths, for example "metrics.latency.p99" or "tags.0".
+// The header is made of the field paths found in the first rows, in order of appearance, followed by the requested paths that first appear later, see addPaths.
+type ndjsonSource struct {
+	reader  *bufio.Reader
+	header  []string
+	index   map[string]int
+	pending []ndjsonRow
+	line    int
+	// Indicates if the field paths that are not in the header are appended to it as they are found, for sources read whole, like the tables of a join.
+	grow bool
+}
+
+// ndjsonRow - Flattened object and the line it was read from.
+type ndjsonRow struct {
+	values map[string]string
+	line   int
+}
+
+// isNDJSON - Indicates if the sample looks like newline delimited JSON, that is, if it starts with an object.
+func isNDJSON(sample []byte) bool {
+	s := strings.TrimLeft(string(sample), "\ufeff \t\r\n")
+	return strings.HasPrefix(s, "{")
+}
+
+// newNDJSONSource - Returns a source for the newline delimited JSON in `reader`.
+// The first rows are read ahead to build the header.
+func newNDJSONSource(reader io.Reader) (*ndjsonSource, error) {
+	s := &ndjsonSource{reader: bufio.NewReader(reader), index: make(map[string]int)}
+	for len(s.pending) < sniffRows {
+		row, keys, err := s.next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		s.addKeys(keys)
+		s.pending = append(s.pending, row)
+	}
+	return s, nil
+}
+
+// addKeys - Appends to the header the field paths that are not in it.
+func (s *ndjsonSource) addKeys(keys []string) {
+	for _, key := range keys {
+		if _, ok := s.index[key]; !ok {
+			s.index[key] = len(s.header)
+			s.header = append(s.header, key)
+		}
+	}
+}
+
+// Header - Returns the field paths used as header.
+func (s *ndjsonSource) Header() []string {
+	return s.header
+}
+
+// addPaths - Appends to the header the field paths that don't resolve against it, so fields that first appear after the rows read ahead can be requested by their exact path.
+// Indexes and glob patterns are left to fail when resolved.
+func (s *ndjsonSource) addPaths(specs ...string) {
+	for _, spec := range specs {
+		if strings.ContainsAny(spec, "*?[\\") {
+			continue
+		}
+		if _, err := resolveColumn(s.header, spec); err == nil {
+			continue
+		}
+		s.index[spec] = len(s.header)
+		s.header = append(s.header, spec)
+	}
+}
+
+// Read - Returns the next record with its fields in header order, io.EOF when there are no more records.
+// Fields that are not in the header are ignored and fields missing from the object are returned empty.
+func (s *ndjsonSource) Read() ([]string, error) {
+	var row ndjsonRow
+	if len(s.pending) > 0 {
+		row, s.pending = s.pending[0], s.pending[1:]
+	} else {
+		var keys []string
+		var err error
+		row, keys, err = s.next()
+		if err != nil {
+			return nil, err
+		}
+		if s.grow {
+			s.addKeys(keys)
+		}
+	}
+	s.line = row.line
+	record := make([]string, len(s.header))
+	for key, value := range row.values {
+		if i, ok := s.index[key]; ok {
+			record[i] = value
+		}
+	}
+	return record, nil
+}
+
+// Line - Returns the line number of the last record read.
+func (s *ndjsonSource) Line() int {
+	return s.line
+}
+
+// next - Reads and flattens the next non empty line.
+// Returns the flattened values and the field paths in the order they appear in the object.
+func (s *ndjsonSource) next() (ndjsonRow, []string, error) {
+	for {
+		line, err := s.reader.ReadString('\n')
+		if err != nil && (err != io.EOF || line == "") {
+			return ndjsonRow{}, nil, err
+		}
+		s.line++
+		line = strings.TrimSpace(strings.TrimPrefix(line, "\ufeff"))
+		if line == "" {
+			continue
+		}
+		row := ndjsonRow{values: make(map[string]string), line: s.line}
+		var keys []string
+		dec := json.NewDecoder(strings.NewReader(line))
+		dec.UseNumber()
+		token, err := dec.Token()
+		if err != nil || token != json.Delim('{') {
+			return row, nil, fmt.Errorf("ndjson: line %d: expected a JSON object", s.line)
+		}
+		err = flattenJSONObject(dec, "", &keys, row.values)
+		if err != nil {
+			return row, nil, fmt.Errorf("ndjson: line %d: %s", s.line, err)
+		}
+		if dec.More() {
+			return row, nil, fmt.Errorf("ndjson: line %d: unexpected data after the JSON object", s.line)
+		}
+		return row, keys, nil
+	}
+}
+
+// flattenJSONObject - Flattens the members of the object that was just opened in `dec`.
+func flattenJSONObject(dec *json.Decoder, prefix string, keys *[]string, values map[string]string) error {
+	for dec.More() {
+		token, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		err = flattenJSON(dec, fieldPath(prefix, token.(string)), keys, values)
+		if err != nil {
+			return err
+		}
+	}
+	// Closing delimiter.
+	_, err := dec.Token()
+	return err
+}
+
+// flattenJSON - Flattens the next JSON value in `dec` under the given field path.
+// Array elements are addressed by their 0-based index and null values are empty.
+func flattenJSON(dec *json.Decoder, path string, keys *[]string, values map[string]string) error {
+	token, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	value := ""
+	switch v := token.(type) {
+	case json.Delim:
+		if v == '{' {
+			return flattenJSONObject(dec, path, keys, values)
+		}
+		for i := 0; dec.More(); i++ {
+			err = flattenJSON(dec, fieldPath(path, strconv.Itoa(i)), keys, values)
+			if err != nil {
+				return err
+			}
+		}
+		_, err = dec.Token()
+		return err
+	case string:
+		value = v
+	case json.Number:
+		value = v.String()
+	case bool:
+		value = strconv.FormatBool(v)
+	}
+	if _, ok := values[path]; !ok {
+		*keys = append(*keys, path)
+	}
+	values[path] = value
+	return nil
+}
+
+// fieldPath - Joins a field name to its parent path.
+func fieldPath(prefix, name string) string {
+	if prefix == "" {
+		return name
+	}
+	return prefix + "." + name
+}
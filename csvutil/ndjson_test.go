@@ -0,0 +1,93 @@
+// This file is part of csv-analysis.
+//
+// Copyright (C) 2017  David Gamba Rios
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+package csvutil
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestNDJSONReader(t *testing.T) {
+	data := `{"time": "2018-01-01T00:00:00Z", "metrics": {"latency": {"p50": 1.5, "p99": 9}}, "tags": ["a", "b"], "ok": true}
+
+{"time": "2018-01-02T00:00:00Z", "metrics": {"latency": {"p99": 1e3}}, "ok": null, "extra": {"x": 1}}
+`
+	tests := []struct {
+		columns  []string
+		header   []string
+		expected [][]string
+		lines    []int
+	}{
+		{[]string{"metrics.latency.p99", "tags.1", "OK"},
+			[]string{"time", "metrics.latency.p50", "metrics.latency.p99", "tags.0", "tags.1", "ok", "extra.x"},
+			[][]string{
+				[]string{"9", "b", "true"},
+				[]string{"1e3", "", ""},
+			}, []int{1, 3}},
+		{[]string{"metrics.latency.p5*", "extra.x"},
+			[]string{"time", "metrics.latency.p50", "metrics.latency.p99", "tags.0", "tags.1", "ok", "extra.x"},
+			[][]string{
+				[]string{"1.5", ""},
+				[]string{"", "1"},
+			}, []int{1, 3}},
+	}
+	for _, test := range tests {
+		cf := New()
+		// NDJSON always has a header.
+		cf.NoHeader = true
+		r := cf.NewReader(strings.NewReader(data), "test.json", test.columns...)
+		records := [][]string{}
+		lines := []int{}
+		for r.Next() {
+			records = append(records, r.Record())
+			lines = append(lines, r.Line())
+		}
+		if err := r.Err(); err != nil {
+			t.Fatalf("Unexpected error: %s\n", err)
+		}
+		if !reflect.DeepEqual(r.Header(), test.header) {
+			t.Errorf("Wrong header: %v != %v\n", r.Header(), test.header)
+		}
+		if !reflect.DeepEqual(records, test.expected) {
+			t.Errorf("Wrong data: %v != %v\n", records, test.expected)
+		}
+		if !reflect.DeepEqual(lines, test.lines) {
+			t.Errorf("Wrong lines: %v != %v\n", lines, test.lines)
+		}
+	}
+	// Fields that first appear after the rows read ahead.
+	late := strings.Repeat("{\"a\": 1}\n", 12) + "{\"a\": 2, \"err\": {\"code\": 500}}\n"
+	cf := New()
+	cf.Where, _ = ParseExpression("err.code >= 500")
+	r := cf.NewReader(strings.NewReader(late), "test.json", "a", "err.code")
+	records := [][]string{}
+	for r.Next() {
+		records = append(records, r.Record())
+	}
+	if err := r.Err(); err != nil {
+		t.Fatalf("Unexpected error: %s\n", err)
+	}
+	if !reflect.DeepEqual(records, [][]string{{"2", "500"}}) {
+		t.Errorf("Wrong data: %v\n", records)
+	}
+	r = New().NewReader(strings.NewReader(late), "test.json", "err.*")
+	for r.Next() {
+	}
+	if r.Err() == nil {
+		t.Errorf("Expected error for an unmatched pattern\n")
+	}
+
+	cf = New()
+	r = cf.NewReader(strings.NewReader("{\"a\": 1}\n[1, 2]\n"), "test.json", "a")
+	for r.Next() {
+	}
+	if r.Err() == nil || r.Err().Error() != "test.json: ndjson: line 2: expected a JSON object" {
+		t.Errorf("Unexpected error: %v\n", r.Err())
+	}
+}
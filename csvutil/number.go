@@ -0,0 +1,231 @@
+// This file is part of csv-analysis.
+//
+// Copyright (C) 2017  David Gamba Rios
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package csvutil
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// NumberFormat - Parses numbers written for humans, like "1,234.5", "1.234,5", "12%", "$3.20", "250ms" or "1.5 GB".
+//
+// Currency symbols and codes are stripped, as well as the percent sign, "12%" is parsed as 12.
+// Negative numbers can be written in accounting notation, "(3.20)" is parsed as -3.2.
+// Unit suffixes are normalised to their base unit:
+//   - Durations to seconds: ns, us, µs, ms, s, m, min, h and d, "m" is minutes.
+//     Go durations like "1h30m" are accepted as well.
+//   - Bytes with SI and IEC prefixes to bytes: B, kB, MB, GB, TB, PB, EB and KiB, MiB, GiB, TiB, PiB, EiB.
+//   - Bare SI prefixes: k, M, G, T, P and E.
+type NumberFormat struct {
+	// Decimal separator.
+	Decimal rune
+	// Thousands separators, digits after a thousands separator must be in groups of 3.
+	Thousands []rune
+}
+
+// Number formats by language.
+var (
+	pointFormat      = NumberFormat{Decimal: '.', Thousands: []rune{','}}
+	commaFormat      = NumberFormat{Decimal: ',', Thousands: []rune{'.'}}
+	spaceFormat      = NumberFormat{Decimal: ',', Thousands: []rune{' ', '\u00a0', '\u202f'}}
+	apostropheFormat = NumberFormat{Decimal: '.', Thousands: []rune{'\'', '’'}}
+)
+
+var localeFormats = map[string]NumberFormat{
+	"c": pointFormat, "en": pointFormat, "ja": pointFormat, "ko": pointFormat, "zh": pointFormat, "he": pointFormat, "th": pointFormat,
+	"de": commaFormat, "es": commaFormat, "it": commaFormat, "nl": commaFormat, "pt": commaFormat, "da": commaFormat,
+	"id": commaFormat, "tr": commaFormat, "el": commaFormat, "ro": commaFormat,
+	"fr": spaceFormat, "ru": spaceFormat, "pl": spaceFormat, "cs": spaceFormat, "sk": spaceFormat, "sv": spaceFormat,
+	"fi": spaceFormat, "nb": spaceFormat, "no": spaceFormat, "uk": spaceFormat, "hu": spaceFormat, "bg": spaceFormat,
+	"ch": apostropheFormat, "de_ch": apostropheFormat, "it_ch": apostropheFormat,
+}
+
+// currencySymbols - Currency symbols and codes stripped from numbers.
+var currencySymbols = []string{
+	"USD", "EUR", "GBP", "JPY", "CHF", "CAD", "AUD", "CNY", "INR", "SEK", "NOK", "DKK", "PLN", "BRL", "MXN",
+	"US$", "R$", "$", "€", "£", "¥", "₹", "₩", "₽", "₺", "¢", "kr", "zł",
+}
+
+// unitMultipliers - Unit suffixes and their value in the base unit.
+var unitMultipliers = map[string]float64{
+	// Durations, in seconds.
+	"ns": 1e-9, "us": 1e-6, "µs": 1e-6, "μs": 1e-6, "ms": 1e-3, "s": 1, "sec": 1,
+	"m": 60, "min": 60, "h": 3600, "d": 86400,
+	// Bytes, SI prefixes.
+	"B": 1, "kB": 1e3, "KB": 1e3, "MB": 1e6, "GB": 1e9, "TB": 1e12, "PB": 1e15, "EB": 1e18,
+	// Bytes, IEC prefixes.
+	"KiB": 1 << 10, "MiB": 1 << 20, "GiB": 1 << 30, "TiB": 1 << 40, "PiB": 1 << 50, "EiB": 1 << 60,
+	// Bare SI prefixes.
+	"k": 1e3, "K": 1e3, "M": 1e6, "G": 1e9, "T": 1e12, "P": 1e15, "E": 1e18,
+}
+
+// NewNumberFormat - Returns the number format for the given locale, like "en", "de", "fr_FR.UTF-8" or "de-CH".
+// The locale is matched by language, "ch" stands for Swiss formatting, "1'234.5".
+func NewNumberFormat(locale string) (NumberFormat, error) {
+	name := strings.ToLower(strings.Replace(locale, "-", "_", -1))
+	if i := strings.Index(name, "."); i >= 0 {
+		name = name[:i]
+	}
+	if f, ok := localeFormats[name]; ok {
+		return f, nil
+	}
+	if i := strings.Index(name, "_"); i >= 0 {
+		if f, ok := localeFormats[name[:i]]; ok {
+			return f, nil
+		}
+	}
+	return NumberFormat{}, fmt.Errorf("unknown locale '%s'", locale)
+}
+
+// ParseFloat - Parses `s` according to the number format.
+func (f NumberFormat) ParseFloat(s string) (float64, error) {
+	value := strings.TrimSpace(s)
+	negative := false
+	if strings.HasPrefix(value, "(") && strings.HasSuffix(value, ")") {
+		negative = true
+		value = strings.TrimSpace(value[1 : len(value)-1])
+	}
+	value = strings.TrimSpace(strings.TrimSuffix(value, "%"))
+	value, sign := trimSign(value)
+	value = trimCurrency(value)
+	value, s2 := trimSign(value)
+	if sign == "" {
+		sign = s2
+	}
+	number, unit := f.splitUnit(value)
+	if number == "" {
+		return 0, fmt.Errorf("invalid number '%s'", s)
+	}
+	multiplier, ok := unitMultipliers[unit]
+	if unit == "" {
+		multiplier = 1
+	} else if !ok {
+		d, err := time.ParseDuration(sign + value)
+		if err != nil {
+			return 0, fmt.Errorf("unknown unit '%s' in '%s'", unit, s)
+		}
+		if negative {
+			return -d.Seconds(), nil
+		}
+		return d.Seconds(), nil
+	}
+	number, err := f.normalise(number)
+	if err != nil {
+		return 0, fmt.Errorf("invalid number '%s': %s", s, err)
+	}
+	x, err := strconv.ParseFloat(sign+number, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid number '%s'", s)
+	}
+	x *= multiplier
+	if negative {
+		x = -x
+	}
+	return x, nil
+}
+
+// trimSign - Splits the leading sign from `s`.
+func trimSign(s string) (string, string) {
+	if strings.HasPrefix(s, "-") || strings.HasPrefix(s, "+") {
+		return strings.TrimSpace(s[1:]), s[:1]
+	}
+	if strings.HasPrefix(s, "−") {
+		return strings.TrimSpace(strings.TrimPrefix(s, "−")), "-"
+	}
+	return s, ""
+}
+
+// trimCurrency - Strips a leading or trailing currency symbol or code from `s`.
+func trimCurrency(s string) string {
+	for _, c := range currencySymbols {
+		if strings.HasPrefix(s, c) {
+			return strings.TrimSpace(strings.TrimPrefix(s, c))
+		}
+		if strings.HasSuffix(s, c) {
+			return strings.TrimSpace(strings.TrimSuffix(s, c))
+		}
+	}
+	return s
+}
+
+// splitUnit - Splits `s` into its number and its unit suffix.
+// A trailing exponent is part of the number, "1e3" is 1000 and "1E" is 1 exa.
+func (f NumberFormat) splitUnit(s string) (string, string) {
+	end := 0
+	for i, c := range s {
+		if (c < '0' || c > '9') && c != f.Decimal && !f.isThousands(c) {
+			break
+		}
+		end = i + len(string(c))
+	}
+	if end < len(s) && (s[end] == 'e' || s[end] == 'E') {
+		exp := end + 1
+		if exp < len(s) && (s[exp] == '-' || s[exp] == '+') {
+			exp++
+		}
+		digits := exp
+		for digits < len(s) && s[digits] >= '0' && s[digits] <= '9' {
+			digits++
+		}
+		if digits > exp {
+			end = digits
+		}
+	}
+	return strings.TrimRightFunc(s[:end], f.isThousands), strings.TrimSpace(s[end:])
+}
+
+// isThousands - Indicates if `c` is a thousands separator.
+func (f NumberFormat) isThousands(c rune) bool {
+	for _, t := range f.Thousands {
+		if c == t {
+			return true
+		}
+	}
+	return false
+}
+
+// normalise - Removes the thousands separators from `s` and converts the decimal separator to '.'.
+// Digits after a thousands separator must be in groups of 3, so "1,5" is rejected instead of read as 15.
+func (f NumberFormat) normalise(s string) (string, error) {
+	var b strings.Builder
+	group := -1
+	decimal := false
+	for _, c := range s {
+		switch {
+		case f.isThousands(c):
+			if decimal || b.Len() == 0 || group == 0 || group > 0 && group != 3 {
+				return "", fmt.Errorf("misplaced thousands separator")
+			}
+			group = 0
+		case c == f.Decimal:
+			if decimal || group > 0 && group != 3 || group == 0 {
+				return "", fmt.Errorf("misplaced decimal separator")
+			}
+			decimal = true
+			b.WriteRune('.')
+		case c == 'e' || c == 'E':
+			if group > 0 && group != 3 {
+				return "", fmt.Errorf("misplaced thousands separator")
+			}
+			decimal = true
+			b.WriteRune(c)
+		default:
+			if group >= 0 && !decimal {
+				group++
+			}
+			b.WriteRune(c)
+		}
+	}
+	if !decimal && group >= 0 && group != 3 {
+		return "", fmt.Errorf("misplaced thousands separator")
+	}
+	return b.String(), nil
+}
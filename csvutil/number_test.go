@@ -0,0 +1,138 @@
+// This file is part of csv-analysis.
+//
+// Copyright (C) 2017  David Gamba Rios
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+package csvutil
+
+import (
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestNumberFormat(t *testing.T) {
+	tests := []struct {
+		locale   string
+		in       string
+		expected float64
+		err      bool
+	}{
+		{"en", "1,234.5", 1234.5, false},
+		{"en", "-1,234,567", -1234567, false},
+		{"en", "1e3", 1000, false},
+		{"en", "12%", 12, false},
+		{"en", "$3.20", 3.2, false},
+		{"en", "-$3.20", -3.2, false},
+		{"en", "(1,000.50 USD)", -1000.5, false},
+		{"en", "250ms", 0.25, false},
+		{"en", "1h30m", 5400, false},
+		{"en", "2m", 120, false},
+		{"en", "1.5 GB", 1.5e9, false},
+		{"en", "2 KiB", 2048, false},
+		{"en", "3.5k", 3500, false},
+		{"en", "1,5", 0, true},
+		{"en", "1,2345", 0, true},
+		{"en", ",5", 0, true},
+		{"en", "12 parsecs", 0, true},
+		{"en", "abc", 0, true},
+		{"de_DE.UTF-8", "1.234,5", 1234.5, false},
+		{"de", "12,5 %", 12.5, false},
+		{"de", "3,20 €", 3.2, false},
+		{"de", "1,5 GB", 1.5e9, false},
+		{"de", "1.5", 0, true},
+		{"fr", "1 234,5", 1234.5, false},
+		{"fr", "1 234,5 ms", 1.2345, false},
+		{"de-CH", "1'234.5", 1234.5, false},
+	}
+	for _, test := range tests {
+		f, err := NewNumberFormat(test.locale)
+		if err != nil {
+			t.Fatalf("Unexpected error: %s\n", err)
+		}
+		x, err := f.ParseFloat(test.in)
+		if test.err {
+			if err == nil {
+				t.Errorf("Expected error not thrown for %s: %v\n", test.in, x)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Unexpected error: %s\n", err)
+			continue
+		}
+		if math.Abs(x-test.expected) > 1e-9 {
+			t.Errorf("Wrong value for %s: %v != %v\n", test.in, x, test.expected)
+		}
+	}
+	_, err := NewNumberFormat("xx_XX")
+	if err == nil || err.Error() != "unknown locale 'xx_XX'" {
+		t.Errorf("Unexpected error: %v\n", err)
+	}
+}
+
+func TestNumberParsers(t *testing.T) {
+	data := "price;latency;size\n\"1.234,50 €\";250ms;1,5 GB\n\"12,00 €\";1.5s;\n"
+	de, _ := NewNumberFormat("de")
+	en, _ := NewNumberFormat("en")
+	cf := New()
+	cf.Parser = de.ParseFloat
+	// Resolved against the header, regardless of how the column is requested.
+	cf.Parsers = map[string]func(string) (float64, error){"Latency": en.ParseFloat}
+	cf.Missing = FillNaN
+	r := cf.NewReader(strings.NewReader(data), "test.csv", "price", "2", "size")
+	var rows [][]float64
+	for r.Next() {
+		row, err := r.Float64Record()
+		if err != nil {
+			t.Fatalf("Unexpected error: %s\n", err)
+		}
+		rows = append(rows, row)
+	}
+	if err := r.Err(); err != nil {
+		t.Fatalf("Unexpected error: %s\n", err)
+	}
+	if len(rows) != 2 || rows[0][0] != 1234.5 || rows[0][1] != 0.25 || rows[0][2] != 1.5e9 ||
+		rows[1][0] != 12 || rows[1][1] != 1.5 || !math.IsNaN(rows[1][2]) {
+		t.Errorf("Wrong data: %v\n", rows)
+	}
+	if cf.Report.Count() != 0 {
+		t.Errorf("Unexpected errors: %v\n", cf.Report.Errors)
+	}
+}
+
+func TestComputedNumberFormat(t *testing.T) {
+	data := "bytes;duration\n\"3.003,00\";2\n\"1.000\";4\n"
+	de, _ := NewNumberFormat("de")
+	cf := New()
+	cf.Parser = de.ParseFloat
+	for _, c := range []string{"throughput=bytes / duration", "half=throughput / 2"} {
+		computed, err := ParseComputedColumn(c)
+		if err != nil {
+			t.Fatalf("Unexpected error: %s\n", err)
+		}
+		cf.Computed = append(cf.Computed, computed)
+	}
+	cf.Where, _ = ParseExpression("throughput > 1000")
+	r := cf.NewReader(strings.NewReader(data), "test.csv", "throughput", "half")
+	var rows [][]float64
+	for r.Next() {
+		row, err := r.Float64Record()
+		if err != nil {
+			t.Fatalf("Unexpected error: %s\n", err)
+		}
+		rows = append(rows, row)
+	}
+	if err := r.Err(); err != nil {
+		t.Fatalf("Unexpected error: %s\n", err)
+	}
+	if !reflect.DeepEqual(rows, [][]float64{{1501.5, 750.75}}) {
+		t.Errorf("Wrong data: %v\n", rows)
+	}
+	if cf.Report.Count() != 0 {
+		t.Errorf("Unexpected errors: %v\n", cf.Report.Errors)
+	}
+}
@@ -0,0 +1,495 @@
+// This file is part of csv-analysis.
+//
+// Copyright (C) 2017  David Gamba Rios
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package csvutil
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Stdin - File name that reads from STDIN.
+const Stdin = "-"
+
+// SourceColumn - Name of the virtual column with the name of the file each record comes from.
+// It is only added when referenced and the file doesn't have a column with that name.
+// Records of joined files have the names of the files joined with "+".
+const SourceColumn = "source"
+
+// Reader - Iterates over the records of a csv source, an xlsx worksheet or NDJSON, one record at a time.
+//
+//	r := cf.NewReader(os.Stdin, "stdin", "latency")
+//	for r.Next() {
+//		fmt.Println(r.Record())
+//	}
+//	if err := r.Err(); err != nil {
+//		...
+//	}
+type Reader struct {
+	cf      *CSVFiles
+	reader  io.Reader
+	name    string
+	columns []string
+	src     source
+	started bool
+	header  []string
+	indexes []int
+	raw     []string
+	line    int
+	where   *evalContext
+	// Parse functions of Parsers by the 1-based index of their column in the header.
+	parsers map[int]func(string) (float64, error)
+	// For each column of the header of the first file, its 0-based index in the record, when the columns are realigned.
+	align []int
+	// Virtual columns, the source and the computed columns, are appended after the first width fields of each record.
+	computed []func(record []string) string
+	width    int
+	pending  []string
+	err      error
+}
+
+// NewReader - Returns a *csvutil.Reader over `reader` for the requested columns, using the settings of *csvutil.CSVFiles.
+// Columns can be given as 1-based indexes or as header names, see resolveColumn.
+// When no columns are requested, every field of the record is returned.
+// The name is used to identify the source in errors.
+func (cf *CSVFiles) NewReader(reader io.Reader, name string, columns ...string) *Reader {
+	return &Reader{cf: cf, reader: reader, name: name, columns: columns}
+}
+
+// Next - Advances to the next record, skipping the records that don't match the Where filter.
+// It returns false when there are no more records or when there is an error, check Err to tell them apart.
+func (r *Reader) Next() bool {
+	if r.err != nil {
+		return false
+	}
+	if !r.started && !r.start() {
+		return false
+	}
+	for {
+		record := r.pending
+		r.pending = nil
+		if record == nil {
+			var err error
+			record, err = r.src.Read()
+			if err == io.EOF {
+				return false
+			}
+			if err != nil {
+				r.err = r.errorf("%s", err)
+				return false
+			}
+		}
+		r.line = r.src.Line()
+		if r.align != nil {
+			record = r.realign(record)
+		}
+		if len(r.computed) > 0 {
+			record = r.compute(record)
+		}
+		r.raw = record
+		if r.where == nil || r.cf.Where.match(r.where, record) {
+			return true
+		}
+	}
+}
+
+// compute - Returns the record padded to the width of the file with the virtual columns appended.
+func (r *Reader) compute(record []string) []string {
+	extended := make([]string, r.width, r.width+len(r.computed))
+	copy(extended, record)
+	for _, value := range r.computed {
+		extended = append(extended, value(extended))
+	}
+	return extended
+}
+
+// start - Sets up the source, reads and checks the header and resolves the requested columns and the columns of the Where filter.
+func (r *Reader) start() bool {
+	r.started = true
+	var err error
+	if r.src == nil {
+		r.src, err = r.cf.newSource(r.reader)
+		if err != nil {
+			r.err = r.errorf("%s", err)
+			return false
+		}
+	}
+	if hs, ok := r.src.(headerSource); ok {
+		if ns, ok := hs.(*ndjsonSource); ok {
+			ns.addPaths(r.fieldSpecs()...)
+		}
+		r.header = hs.Header()
+	} else if !r.cf.NoHeader {
+		r.header, err = r.src.Read()
+		if err == io.EOF {
+			return false
+		}
+		if err != nil {
+			r.err = r.errorf("%s", err)
+			return false
+		}
+	}
+	err = r.checkHeader()
+	if err != nil {
+		r.err = r.errorf("%s", err)
+		return false
+	}
+	r.parsers = r.cf.resolveParsers(r.header)
+	header := r.header
+	if len(r.cf.Computed) > 0 || r.usesSource() {
+		header, err = r.startComputed()
+		if err != nil {
+			r.err = r.errorf("%s", err)
+			return false
+		}
+	}
+	r.indexes, err = resolveColumns(header, r.columns...)
+	if err != nil {
+		r.err = r.errorf("%s", err)
+		return false
+	}
+	for _, c := range r.indexes {
+		if c <= 0 {
+			r.err = r.errorf("Column index error: %d <= 0!", c)
+			return false
+		}
+	}
+	if r.cf.Where != nil {
+		r.where, err = r.cf.Where.bind(r, header)
+		if err != nil {
+			r.err = r.errorf("where: %s", err)
+			return false
+		}
+	}
+	return true
+}
+
+// startComputed - Binds the virtual columns and returns the header with their names appended.
+// The source column, when used, comes first so computed columns can reference it, as well as the columns of the file and the computed columns defined before them.
+// When there is no header, the width of the file is taken from the first record and the returned header has empty names for the columns of the file.
+// Fields beyond the width of the file are dropped so the computed columns keep their index.
+func (r *Reader) startComputed() ([]string, error) {
+	header := append([]string{}, r.header...)
+	if r.header == nil {
+		record, err := r.src.Read()
+		if err != nil && err != io.EOF {
+			return nil, err
+		}
+		r.pending = record
+		header = make([]string, len(record))
+	}
+	r.width = len(header)
+	if r.usesSource() {
+		r.computed = append(r.computed, func([]string) string { return r.name })
+		header = append(header, SourceColumn)
+	}
+	for _, c := range r.cf.Computed {
+		c := c
+		ctx, err := c.Expression.bind(r, header)
+		if err != nil {
+			return nil, fmt.Errorf("computed column '%s': %s", c.Name, err)
+		}
+		r.computed = append(r.computed, func(record []string) string { return c.Expression.value(ctx, record) })
+		header = append(header, c.Name)
+	}
+	if r.header != nil {
+		r.header = header
+	}
+	return header, nil
+}
+
+// usesSource - Indicates if the source column is referenced by the requested columns, the Where filter or the computed columns.
+// A column of the file with the same name takes precedence.
+func (r *Reader) usesSource() bool {
+	for _, name := range r.header {
+		if strings.EqualFold(strings.TrimSpace(name), SourceColumn) {
+			return false
+		}
+	}
+	for _, spec := range r.specs() {
+		if strings.EqualFold(strings.TrimSpace(spec), SourceColumn) {
+			return true
+		}
+	}
+	return false
+}
+
+// specs - Returns the column specs referenced by the requested columns, the Where filter and the computed columns.
+func (r *Reader) specs() []string {
+	specs := append([]string{}, r.columns...)
+	if r.cf.Where != nil {
+		specs = append(specs, r.cf.Where.Columns()...)
+	}
+	for _, c := range r.cf.Computed {
+		specs = append(specs, c.Expression.Columns()...)
+	}
+	return specs
+}
+
+// fieldSpecs - Returns the referenced column specs, see specs, without the virtual columns.
+func (r *Reader) fieldSpecs() []string {
+	var fields []string
+	for _, spec := range r.specs() {
+		virtual := strings.EqualFold(strings.TrimSpace(spec), SourceColumn)
+		for _, c := range r.cf.Computed {
+			virtual = virtual || strings.EqualFold(strings.TrimSpace(spec), c.Name)
+		}
+		if !virtual {
+			fields = append(fields, spec)
+		}
+	}
+	return fields
+}
+
+// errorf - Returns an error prefixed with the source name.
+func (r *Reader) errorf(format string, a ...interface{}) error {
+	if r.name == "" {
+		return fmt.Errorf(format, a...)
+	}
+	return fmt.Errorf("%s: "+format, append([]interface{}{r.name}, a...)...)
+}
+
+// Err - Returns the error that stopped the iteration, if any.
+func (r *Reader) Err() error {
+	return r.err
+}
+
+// Header - Returns the header row, nil when the source has no header.
+// Only available after the first call to Next.
+func (r *Reader) Header() []string {
+	return r.header
+}
+
+// Indexes - Returns the 1-based indexes of the requested columns.
+// Only available after the first call to Next.
+func (r *Reader) Indexes() []int {
+	return r.indexes
+}
+
+// Line - Returns the line number where the current record starts.
+func (r *Reader) Line() int {
+	return r.line
+}
+
+// Raw - Returns every field of the current record.
+func (r *Reader) Raw() []string {
+	return r.raw
+}
+
+// Record - Returns the requested columns of the current record.
+// Fields missing from short records are returned as empty strings.
+func (r *Reader) Record() []string {
+	if len(r.columns) == 0 {
+		return r.raw
+	}
+	fields := make([]string, len(r.indexes))
+	for i, index := range r.indexes {
+		if len(r.raw) >= index {
+			fields[i] = r.raw[index-1]
+		}
+	}
+	return fields
+}
+
+// source - Provides the records of a data source.
+type source interface {
+	// Read - Returns the next record, io.EOF when there are no more records.
+	Read() ([]string, error)
+	// Line - Returns the line number where the last record read starts.
+	Line() int
+}
+
+// headerSource - Source that provides its own header, like NDJSON field paths.
+// NoHeader doesn't apply to them.
+type headerSource interface {
+	source
+	Header() []string
+}
+
+// csvSource - Records from a csv file.
+type csvSource struct {
+	*csv.Reader
+	skipLines int
+}
+
+// Line - Returns the line number where the last record read starts.
+func (s *csvSource) Line() int {
+	line, _ := s.FieldPos(0)
+	return line + s.skipLines
+}
+
+// peek - Returns up to the next `n` bytes of `br` without advancing it.
+func peek(br *bufio.Reader, n int) []byte {
+	// Peek returns what is available on a short read, the error is not relevant here.
+	b, _ := br.Peek(n)
+	return b
+}
+
+// newSource - Returns the source for `reader` based on its content.
+// xlsx workbooks are detected by their zip magic bytes and NDJSON by a leading '{', anything else is read as csv.
+func (cf *CSVFiles) newSource(reader io.Reader) (source, error) {
+	br := bufio.NewReader(reader)
+	magic := peek(br, len(zipMagic))
+	if bytes.Equal(magic, zipMagic) {
+		return newXLSXSource(br, cf.Sheet, cf.CellRange)
+	}
+	// Leading white space is not expected to be long.
+	sample := peek(br, 1024)
+	if isNDJSON(sample) {
+		return newNDJSONSource(br)
+	}
+	r, err := newCSVReader(br, cf.Dialect)
+	if err != nil {
+		return nil, err
+	}
+	return &csvSource{Reader: r, skipLines: cf.Dialect.SkipLines}, nil
+}
+
+// Float64Record - Returns the requested columns of the current record as floats.
+// Values that are empty, unparsable or zero when FilterZero is set are returned as NaN.
+// Unparsable values are added to the Report, the error is only returned when it should abort the reading.
+func (r *Reader) Float64Record() ([]float64, error) {
+	record := r.Record()
+	row := make([]float64, len(record))
+	for i, value := range record {
+		var err error
+		row[i], err = r.float64Field(i, value)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return row, nil
+}
+
+// float64Field - Parses the value of the i-th requested column, see Float64Record.
+func (r *Reader) float64Field(i int, value string) (float64, error) {
+	_, index := r.column(i)
+	x, err := r.cf.parseFloat64(r.parser(index), value)
+	if err != nil {
+		return x, r.cf.addError(&ParseError{
+			File:   r.name,
+			Line:   r.line,
+			Column: index,
+			Name:   r.columnName(i),
+			Value:  value,
+			Reason: err.Error(),
+		})
+	}
+	return x, nil
+}
+
+// column - Returns the spec and the 1-based index of the i-th requested column.
+// When no columns were requested, every field is a column.
+func (r *Reader) column(i int) (string, int) {
+	if len(r.columns) == 0 {
+		return strconv.Itoa(i + 1), i + 1
+	}
+	return r.columns[i], r.indexes[i]
+}
+
+// columnName - Returns the header name of the i-th requested column, or the column as requested if there is no header.
+func (r *Reader) columnName(i int) string {
+	spec, index := r.column(i)
+	if len(r.header) >= index {
+		return r.header[index-1]
+	}
+	return spec
+}
+
+// parser - Returns the parse function of the column at the given 1-based index.
+// Computed columns hold numbers formatted with strconv.FormatFloat, so they are always parsed with strconv.ParseFloat.
+func (r *Reader) parser(index int) func(string) (float64, error) {
+	if len(r.computed) > 0 && index > r.width {
+		return parseFloat
+	}
+	return r.cf.columnParser(r.parsers, index)
+}
+
+// parseFloat64 - Parses the value with the given parse function.
+// Returns NaN when the value is missing, unparsable or filtered out, only unparsable values return an error.
+func (cf *CSVFiles) parseFloat64(parse func(string) (float64, error), value string) (float64, error) {
+	trimmed := strings.TrimSpace(value)
+	if trimmed == "" {
+		return math.NaN(), nil
+	}
+	x64, err := parse(trimmed)
+	if err != nil {
+		return math.NaN(), err
+	}
+	if cf.FilterZero && x64 == 0 {
+		return math.NaN(), nil
+	}
+	return x64, nil
+}
+
+// resolveParsers - Returns the parse functions of Parsers by the 1-based index of their column, resolved against the header like the requested columns, see resolveColumn.
+// Columns that are not in the header are skipped, they may only be in some of the files.
+// When several specs resolve to the same column, the first one in sorted order wins.
+func (cf *CSVFiles) resolveParsers(header []string) map[int]func(string) (float64, error) {
+	specs := make([]string, 0, len(cf.Parsers))
+	for spec := range cf.Parsers {
+		specs = append(specs, spec)
+	}
+	sort.Strings(specs)
+	parsers := make(map[int]func(string) (float64, error))
+	for _, spec := range specs {
+		index, err := resolveColumn(header, spec)
+		if err != nil || index <= 0 {
+			continue
+		}
+		if _, ok := parsers[index]; !ok {
+			parsers[index] = cf.Parsers[spec]
+		}
+	}
+	return parsers
+}
+
+// columnParser - Returns the parse function of the column at the given 1-based index from the parsers returned by resolveParsers.
+// Defaults to Parser and then to strconv.ParseFloat.
+func (cf *CSVFiles) columnParser(parsers map[int]func(string) (float64, error), index int) func(string) (float64, error) {
+	if p, ok := parsers[index]; ok {
+		return p
+	}
+	if cf.Parser != nil {
+		return cf.Parser
+	}
+	return parseFloat
+}
+
+// parseFloat - Parses a number with strconv.ParseFloat.
+func parseFloat(s string) (float64, error) {
+	return strconv.ParseFloat(s, 64)
+}
+
+// open - Opens the given file for reading, Stdin reads from STDIN.
+// Compressed files are decompressed transparently.
+func (cf *CSVFiles) open(file string) (io.ReadCloser, error) {
+	var fh io.ReadCloser = ioutil.NopCloser(os.Stdin)
+	if file != Stdin {
+		var err error
+		fh, err = os.Open(file)
+		if err != nil {
+			return nil, err
+		}
+	}
+	rc, err := decompress(fh)
+	if err != nil {
+		fh.Close()
+		return nil, fmt.Errorf("%s: %s", file, err)
+	}
+	return rc, nil
+}
@@ -0,0 +1,73 @@
+// This file is part of csv-analysis.
+//
+// Copyright (C) 2017  David Gamba Rios
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package csvutil
+
+import (
+	"fmt"
+	"io"
+)
+
+// ParseError - Describes a value that could not be used.
+type ParseError struct {
+	File   string
+	Line   int    // 1-based line number in the file.
+	Column int    // 1-based column index.
+	Name   string // Column name from the header, or the column as requested when there is no header.
+	Value  string // Raw value.
+	Reason string
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("%s:%d: column %d (%s): '%s': %s", e.File, e.Line, e.Column, e.Name, e.Value, e.Reason)
+}
+
+// Report - Parse errors and warnings collected while reading the files.
+type Report struct {
+	Errors   []*ParseError
+	Warnings []string
+}
+
+// Count - Returns the number of parse errors.
+func (r *Report) Count() int {
+	return len(r.Errors)
+}
+
+// Print - Prints the parse errors and warnings to `w`.
+func (r *Report) Print(w io.Writer) {
+	for _, e := range r.Errors {
+		fmt.Fprintf(w, "ERROR: %s\n", e)
+	}
+	for _, warning := range r.Warnings {
+		fmt.Fprintf(w, "WARNING: %s\n", warning)
+	}
+}
+
+// Reset - Clears the collected parse errors and warnings.
+func (r *Report) Reset() {
+	r.Errors = nil
+	r.Warnings = nil
+}
+
+// addError - Adds the parse error to the report.
+// Returns an error when the error should abort the reading, either because Strict is set or because MaxErrors was reached.
+func (cf *CSVFiles) addError(e *ParseError) error {
+	cf.Report.Errors = append(cf.Report.Errors, e)
+	if cf.Strict {
+		return e
+	}
+	if cf.MaxErrors > 0 && cf.Report.Count() >= cf.MaxErrors {
+		return fmt.Errorf("too many parse errors: %d, last: %s", cf.Report.Count(), e)
+	}
+	return nil
+}
+
+// addWarning - Adds a warning to the report.
+func (cf *CSVFiles) addWarning(format string, a ...interface{}) {
+	cf.Report.Warnings = append(cf.Report.Warnings, fmt.Sprintf(format, a...))
+}
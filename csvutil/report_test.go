@@ -0,0 +1,64 @@
+// This file is part of csv-analysis.
+//
+// Copyright (C) 2017  David Gamba Rios
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+package csvutil
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+)
+
+func TestReport(t *testing.T) {
+	file := tempFile(t, `x,y
+1,10
+2,n/a
+3,?
+`)
+	cf := New(file)
+	_, err := cf.GetFloat64Rows("x", "2")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s\n", err)
+	}
+	if cf.Report.Count() != 2 {
+		t.Fatalf("Wrong error count: %d != %d\n", cf.Report.Count(), 2)
+	}
+	e := cf.Report.Errors[0]
+	expected := ParseError{File: file, Line: 3, Column: 2, Name: "y", Value: "n/a", Reason: `strconv.ParseFloat: parsing "n/a": invalid syntax`}
+	if *e != expected {
+		t.Errorf("Wrong parse error: %v != %v\n", *e, expected)
+	}
+	var buf bytes.Buffer
+	cf.Report.Print(&buf)
+	expectedOutput := fmt.Sprintf(`ERROR: %[1]s:3: column 2 (y): 'n/a': strconv.ParseFloat: parsing "n/a": invalid syntax
+ERROR: %[1]s:4: column 2 (y): '?': strconv.ParseFloat: parsing "?": invalid syntax
+`, file)
+	if buf.String() != expectedOutput {
+		t.Errorf("Wrong output: %s != %s\n", buf.String(), expectedOutput)
+	}
+
+	cf.Report.Reset()
+	cf.Strict = true
+	_, err = cf.GetFloat64Rows("x", "y")
+	if err == nil {
+		t.Fatalf("Expected error not thrown\n")
+	}
+	if pe, ok := err.(*ParseError); !ok || pe.Line != 3 {
+		t.Errorf("Unexpected error: %s\n", err)
+	}
+
+	cf.Report.Reset()
+	cf.Strict = false
+	cf.MaxErrors = 2
+	_, err = cf.GetFloat64Rows("x", "y")
+	if err == nil {
+		t.Fatalf("Expected error not thrown\n")
+	}
+	if cf.Report.Count() != 2 {
+		t.Errorf("Wrong error count: %d != %d\n", cf.Report.Count(), 2)
+	}
+}
@@ -0,0 +1,393 @@
+// This file is part of csv-analysis.
+//
+// Copyright (C) 2017  David Gamba Rios
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package csvutil
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// zipMagic - Magic bytes at the start of zip files, xlsx workbooks are zip files.
+var zipMagic = []byte{'P', 'K', 0x03, 0x04}
+
+// xlsxSource - Reads the rows of a worksheet from an xlsx workbook.
+// Cells formatted as dates are returned in RFC3339 format.
+type xlsxSource struct {
+	decoder    *xml.Decoder
+	strings    []string
+	dateStyles map[int]bool
+	date1904   bool
+	cells      cellRange
+	row        int
+}
+
+// cellRange - Range of cells, 1-based and inclusive. 0 means unbounded.
+type cellRange struct {
+	minCol, maxCol int
+	minRow, maxRow int
+}
+
+type xlsxWorkbook struct {
+	WorkbookPr struct {
+		Date1904 bool `xml:"date1904,attr"`
+	} `xml:"workbookPr"`
+	Sheets []xlsxSheet `xml:"sheets>sheet"`
+}
+
+type xlsxSheet struct {
+	Name string `xml:"name,attr"`
+	ID   string `xml:"id,attr"`
+}
+
+type xlsxRelationships struct {
+	Relationships []struct {
+		ID     string `xml:"Id,attr"`
+		Target string `xml:"Target,attr"`
+	} `xml:"Relationship"`
+}
+
+type xlsxText struct {
+	T string `xml:"t"`
+	R []struct {
+		T string `xml:"t"`
+	} `xml:"r"`
+}
+
+func (t xlsxText) String() string {
+	s := t.T
+	for _, r := range t.R {
+		s += r.T
+	}
+	return s
+}
+
+type xlsxSharedStrings struct {
+	SI []xlsxText `xml:"si"`
+}
+
+type xlsxStyles struct {
+	NumFmts []struct {
+		ID   int    `xml:"numFmtId,attr"`
+		Code string `xml:"formatCode,attr"`
+	} `xml:"numFmts>numFmt"`
+	CellXfs []struct {
+		NumFmtID int `xml:"numFmtId,attr"`
+	} `xml:"cellXfs>xf"`
+}
+
+type xlsxRow struct {
+	R     int `xml:"r,attr"`
+	Cells []struct {
+		R  string    `xml:"r,attr"`
+		T  string    `xml:"t,attr"`
+		S  int       `xml:"s,attr"`
+		V  string    `xml:"v"`
+		IS *xlsxText `xml:"is"`
+	} `xml:"c"`
+}
+
+// newXLSXSource - Reads the xlsx workbook from `reader` and returns a source for the given sheet and cell range.
+// The sheet can be given by name or by 1-based index, see sheetIndex, the first sheet is used when empty.
+// The cell range is given as "A1:D100" or as columns "B:D", the whole sheet is used when empty.
+func newXLSXSource(reader io.Reader, sheet, cells string) (*xlsxSource, error) {
+	cr, err := parseCellRange(cells)
+	if err != nil {
+		return nil, err
+	}
+	data, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("xlsx: %s", err)
+	}
+	files := make(map[string]*zip.File)
+	for _, f := range zr.File {
+		files[f.Name] = f
+	}
+	s := &xlsxSource{cells: cr, dateStyles: make(map[int]bool)}
+
+	var wb xlsxWorkbook
+	err = decodeZipFile(files, "xl/workbook.xml", &wb)
+	if err != nil {
+		return nil, err
+	}
+	s.date1904 = wb.WorkbookPr.Date1904
+	var rels xlsxRelationships
+	err = decodeZipFile(files, "xl/_rels/workbook.xml.rels", &rels)
+	if err != nil {
+		return nil, err
+	}
+	var ss xlsxSharedStrings
+	if _, ok := files["xl/sharedStrings.xml"]; ok {
+		err = decodeZipFile(files, "xl/sharedStrings.xml", &ss)
+		if err != nil {
+			return nil, err
+		}
+	}
+	for _, si := range ss.SI {
+		s.strings = append(s.strings, si.String())
+	}
+	var styles xlsxStyles
+	if _, ok := files["xl/styles.xml"]; ok {
+		err = decodeZipFile(files, "xl/styles.xml", &styles)
+		if err != nil {
+			return nil, err
+		}
+	}
+	customFormats := make(map[int]string)
+	for _, f := range styles.NumFmts {
+		customFormats[f.ID] = f.Code
+	}
+	for i, xf := range styles.CellXfs {
+		s.dateStyles[i] = isDateFormat(xf.NumFmtID, customFormats[xf.NumFmtID])
+	}
+
+	index := sheetIndex(wb.Sheets, sheet)
+	if index < 0 || index >= len(wb.Sheets) {
+		return nil, fmt.Errorf("xlsx: sheet '%s' not found", sheet)
+	}
+	target := ""
+	for _, rel := range rels.Relationships {
+		if rel.ID == wb.Sheets[index].ID {
+			target = rel.Target
+		}
+	}
+	if strings.HasPrefix(target, "/") {
+		target = strings.TrimPrefix(target, "/")
+	} else {
+		target = path.Join("xl", target)
+	}
+	f, ok := files[target]
+	if !ok {
+		return nil, fmt.Errorf("xlsx: worksheet '%s' not found", target)
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return nil, fmt.Errorf("xlsx: %s", err)
+	}
+	defer rc.Close()
+	worksheet, err := ioutil.ReadAll(rc)
+	if err != nil {
+		return nil, fmt.Errorf("xlsx: %s", err)
+	}
+	s.decoder = xml.NewDecoder(bytes.NewReader(worksheet))
+	return s, nil
+}
+
+// sheetIndex - Returns the 0-based index of the sheet, -1 when not found.
+// An exact name match comes first, so sheets named like "2019" can be selected, then the 1-based index and then a case-insensitive name match.
+func sheetIndex(sheets []xlsxSheet, sheet string) int {
+	if sheet == "" {
+		return 0
+	}
+	for i, sh := range sheets {
+		if sh.Name == sheet {
+			return i
+		}
+	}
+	if n, err := strconv.Atoi(sheet); err == nil {
+		return n - 1
+	}
+	for i, sh := range sheets {
+		if strings.EqualFold(sh.Name, sheet) {
+			return i
+		}
+	}
+	return -1
+}
+
+// decodeZipFile - Decodes the xml file with the given name from the zip files.
+func decodeZipFile(files map[string]*zip.File, name string, v interface{}) error {
+	f, ok := files[name]
+	if !ok {
+		return fmt.Errorf("xlsx: '%s' not found", name)
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return fmt.Errorf("xlsx: %s", err)
+	}
+	defer rc.Close()
+	err = xml.NewDecoder(rc).Decode(v)
+	if err != nil {
+		return fmt.Errorf("xlsx: %s: %s", name, err)
+	}
+	return nil
+}
+
+// Read - Returns the next row in the cell range, io.EOF when there are no more rows.
+// Empty rows are skipped.
+func (s *xlsxSource) Read() ([]string, error) {
+	for {
+		token, err := s.decoder.Token()
+		if err != nil {
+			return nil, err
+		}
+		se, ok := token.(xml.StartElement)
+		if !ok || se.Name.Local != "row" {
+			continue
+		}
+		var row xlsxRow
+		err = s.decoder.DecodeElement(&row, &se)
+		if err != nil {
+			return nil, fmt.Errorf("xlsx: %s", err)
+		}
+		if row.R == 0 {
+			row.R = s.row + 1
+		}
+		s.row = row.R
+		if s.row < s.cells.minRow {
+			continue
+		}
+		if s.cells.maxRow > 0 && s.row > s.cells.maxRow {
+			return nil, io.EOF
+		}
+		record := s.record(row)
+		if len(record) == 0 {
+			continue
+		}
+		return record, nil
+	}
+}
+
+// record - Returns the values of the row cells within the cell range.
+func (s *xlsxSource) record(row xlsxRow) []string {
+	var record []string
+	col := 0
+	first := s.cells.minCol
+	if first == 0 {
+		first = 1
+	}
+	for _, c := range row.Cells {
+		col++
+		if c.R != "" {
+			col, _, _ = parseCellRef(c.R)
+		}
+		if col < first || (s.cells.maxCol > 0 && col > s.cells.maxCol) {
+			continue
+		}
+		for len(record) < col-first {
+			record = append(record, "")
+		}
+		record = append(record, s.value(c.T, c.S, c.V, c.IS))
+	}
+	return record
+}
+
+// value - Returns the cell value as a string.
+func (s *xlsxSource) value(t string, style int, v string, is *xlsxText) string {
+	switch t {
+	case "s":
+		i, err := strconv.Atoi(v)
+		if err != nil || i < 0 || i >= len(s.strings) {
+			return v
+		}
+		return s.strings[i]
+	case "inlineStr":
+		if is != nil {
+			return is.String()
+		}
+		return ""
+	case "b":
+		if v == "1" {
+			return "true"
+		}
+		return "false"
+	case "", "n":
+		if s.dateStyles[style] {
+			if serial, err := strconv.ParseFloat(v, 64); err == nil {
+				return excelTime(serial, s.date1904).Format(time.RFC3339)
+			}
+		}
+	}
+	return v
+}
+
+// Line - Returns the row number of the last row read.
+func (s *xlsxSource) Line() int {
+	return s.row
+}
+
+// excelTime - Converts an Excel serial date into a time.
+func excelTime(serial float64, date1904 bool) time.Time {
+	epoch := time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)
+	if date1904 {
+		epoch = time.Date(1904, 1, 1, 0, 0, 0, 0, time.UTC)
+	}
+	days := math.Floor(serial)
+	seconds := math.Round((serial - days) * 24 * 60 * 60)
+	return epoch.AddDate(0, 0, int(days)).Add(time.Duration(seconds) * time.Second)
+}
+
+var dateFormatQuoted = regexp.MustCompile(`"[^"]*"|\[[^\]]*\]|\\.`)
+
+// isDateFormat - Indicates if the number format, given by id or custom format code, is a date or time format.
+func isDateFormat(id int, code string) bool {
+	switch {
+	case id >= 14 && id <= 22, id >= 27 && id <= 36, id >= 45 && id <= 47, id >= 50 && id <= 58:
+		return true
+	case code == "":
+		return false
+	}
+	code = strings.ToLower(dateFormatQuoted.ReplaceAllString(code, ""))
+	return strings.ContainsAny(code, "ymdhs")
+}
+
+// parseCellRange - Parses a cell range like "A1:D100" or "B:D".
+func parseCellRange(s string) (cellRange, error) {
+	var cr cellRange
+	if s == "" {
+		return cr, nil
+	}
+	parts := strings.Split(strings.ToUpper(s), ":")
+	if len(parts) != 2 {
+		return cr, fmt.Errorf("xlsx: cell range '%s' must be like 'A1:D100'", s)
+	}
+	var err error
+	cr.minCol, cr.minRow, err = parseCellRef(parts[0])
+	if err != nil {
+		return cr, fmt.Errorf("xlsx: cell range '%s': %s", s, err)
+	}
+	cr.maxCol, cr.maxRow, err = parseCellRef(parts[1])
+	if err != nil {
+		return cr, fmt.Errorf("xlsx: cell range '%s': %s", s, err)
+	}
+	return cr, nil
+}
+
+// parseCellRef - Parses a cell reference like "AB12" into its 1-based column and row.
+// Either part can be missing, in which case it is returned as 0.
+func parseCellRef(ref string) (col, row int, err error) {
+	ref = strings.ToUpper(strings.TrimSpace(ref))
+	i := 0
+	for ; i < len(ref) && ref[i] >= 'A' && ref[i] <= 'Z'; i++ {
+		col = col*26 + int(ref[i]-'A'+1)
+	}
+	if i < len(ref) {
+		row, err = strconv.Atoi(ref[i:])
+		if err != nil || row <= 0 {
+			return 0, 0, fmt.Errorf("invalid cell reference '%s'", ref)
+		}
+	}
+	if i == 0 && row == 0 {
+		return 0, 0, fmt.Errorf("invalid cell reference '%s'", ref)
+	}
+	return col, row, nil
+}
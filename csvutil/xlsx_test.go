@@ -0,0 +1,140 @@
+// This file is part of csv-analysis.
+//
+// Copyright (C) 2017  David Gamba Rios
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+package csvutil
+
+import (
+	"archive/zip"
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func testWorkbook(t *testing.T) []byte {
+	files := []struct {
+		name, data string
+	}{
+		{"xl/workbook.xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">
+<sheets><sheet name="Notes" sheetId="1" r:id="rId1"/><sheet name="2019" sheetId="2" r:id="rId2"/></sheets>
+</workbook>`},
+		{"xl/_rels/workbook.xml.rels", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>
+<Relationship Id="rId2" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="/xl/worksheets/sheet2.xml"/>
+</Relationships>`},
+		{"xl/sharedStrings.xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<sst xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">
+<si><t>time</t></si><si><r><t>late</t></r><r><t>ncy</t></r></si><si><t>ok</t></si>
+</sst>`},
+		{"xl/styles.xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">
+<numFmts><numFmt numFmtId="164" formatCode="yyyy\-mm\-dd hh:mm"/><numFmt numFmtId="165" formatCode="0.00&quot;ms&quot;"/></numFmts>
+<cellXfs><xf numFmtId="0"/><xf numFmtId="14"/><xf numFmtId="164"/><xf numFmtId="165"/></cellXfs>
+</styleSheet>`},
+		{"xl/worksheets/sheet1.xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>
+<row r="1"><c r="A1" t="inlineStr"><is><t>notes</t></is></c></row>
+</sheetData></worksheet>`},
+		{"xl/worksheets/sheet2.xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>
+<row r="1"><c r="B1" t="s"><v>0</v></c><c r="C1" t="s"><v>1</v></c><c r="D1" t="inlineStr"><is><t>status</t></is></c></row>
+<row r="2"><c r="B2" s="1"><v>43101</v></c><c r="C2" s="3"><v>1.5</v></c><c r="D2" t="s"><v>2</v></c></row>
+<row r="4"><c r="B4" s="2"><v>43102.5</v></c><c r="D4" t="b"><v>1</v></c></row>
+<row r="5"><c r="B5"><v>3</v></c><c r="C5"><v>4</v></c></row>
+</sheetData></worksheet>`},
+	}
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	for _, file := range files {
+		f, err := w.Create(file.name)
+		if err != nil {
+			t.Fatalf("Unexpected error: %s\n", err)
+		}
+		f.Write([]byte(file.data))
+	}
+	w.Close()
+	return buf.Bytes()
+}
+
+func TestXLSXReader(t *testing.T) {
+	data := testWorkbook(t)
+	tests := []struct {
+		sheet, cells string
+		columns      []string
+		expected     [][]string
+		lines        []int
+	}{
+		{"", "", nil, [][]string{}, []int{}},
+		// By name, not index.
+		{"2019", "", []string{"time", "Latency", "4"}, [][]string{
+			[]string{"2018-01-01T00:00:00Z", "1.5", "ok"},
+			[]string{"2018-01-02T12:00:00Z", "", "true"},
+			[]string{"3", "4", ""},
+		}, []int{2, 4, 5}},
+		{"2", "C1:D4", []string{"1", "status"}, [][]string{
+			[]string{"1.5", "ok"},
+			[]string{"", "true"},
+		}, []int{2, 4}},
+	}
+	for _, test := range tests {
+		cf := New()
+		cf.Sheet = test.sheet
+		cf.CellRange = test.cells
+		r := cf.NewReader(bytes.NewReader(data), "test.xlsx", test.columns...)
+		records := [][]string{}
+		lines := []int{}
+		for r.Next() {
+			records = append(records, r.Record())
+			lines = append(lines, r.Line())
+		}
+		if err := r.Err(); err != nil {
+			t.Fatalf("Unexpected error: %s\n", err)
+		}
+		if !reflect.DeepEqual(records, test.expected) {
+			t.Errorf("Wrong data: %v != %v\n", records, test.expected)
+		}
+		if !reflect.DeepEqual(lines, test.lines) {
+			t.Errorf("Wrong lines: %v != %v\n", lines, test.lines)
+		}
+	}
+	cf := New()
+	cf.Sheet = "Missing"
+	r := cf.NewReader(bytes.NewReader(data), "test.xlsx")
+	if r.Next() || r.Err() == nil || r.Err().Error() != "test.xlsx: xlsx: sheet 'Missing' not found" {
+		t.Errorf("Unexpected error: %v\n", r.Err())
+	}
+}
+
+func TestParseCellRange(t *testing.T) {
+	tests := []struct {
+		in       string
+		expected cellRange
+		err      bool
+	}{
+		{"", cellRange{}, false},
+		{"A1:D100", cellRange{minCol: 1, maxCol: 4, minRow: 1, maxRow: 100}, false},
+		{"b:aa", cellRange{minCol: 2, maxCol: 27}, false},
+		{"A1", cellRange{}, true},
+		{"A0:B2", cellRange{}, true},
+	}
+	for _, test := range tests {
+		cr, err := parseCellRange(test.in)
+		if test.err {
+			if err == nil {
+				t.Errorf("Expected error not thrown for %s\n", test.in)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("Unexpected error: %s\n", err)
+		}
+		if cr != test.expected {
+			t.Errorf("Wrong range: %v != %v\n", cr, test.expected)
+		}
+	}
+}
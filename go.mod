@@ -2,7 +2,9 @@ module github.com/DavidGamba/csv-analysis
 
 require (
 	github.com/DavidGamba/go-getoptions v0.11.0
+	github.com/klauspost/compress v1.18.0
 	github.com/montanaflynn/stats v0.0.0-20180722130825-07668e8400fe
+	github.com/ulikunitz/xz v0.5.15
 	gonum.org/v1/gonum v0.0.0-20180816165407-929014505bf4
 	gonum.org/v1/plot v0.0.0-20180810201206-b07a7783ad19
 )
@@ -0,0 +1,251 @@
+// This file is part of csv-analysis.
+//
+// Copyright (C) 2017  David Gamba Rios
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+/*
+Package output writes results as tables in machine readable formats: JSON, CSV, TSV and Markdown, as well as aligned text.
+
+Every result is a Table, a type name and rows with the same columns.
+In JSON, each table is written as an object on its own line, JSON Lines, with the type name and the rows as objects:
+
+	{"type":"summary","rows":[{"count":3,"min":1,"max":3}]}
+
+In CSV, TSV and Markdown, each table is written with a header row and tables are separated by an empty line.
+Numbers that are NaN or infinite are written as null in JSON and as empty values in the other formats.
+*/
+package output
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+// Format - Format of the output.
+type Format int
+
+const (
+	// Text - Human readable text, tables are aligned with spaces.
+	Text Format = iota
+	// JSON - One JSON object per table, per line.
+	JSON
+	// CSV - Comma separated values with a header row.
+	CSV
+	// TSV - Tab separated values with a header row.
+	TSV
+	// Markdown - Markdown pipe tables.
+	Markdown
+)
+
+var formatNames = map[Format]string{
+	Text:     "text",
+	JSON:     "json",
+	CSV:      "csv",
+	TSV:      "tsv",
+	Markdown: "markdown",
+}
+
+func (f Format) String() string {
+	return formatNames[f]
+}
+
+// ParseFormat - Returns the Format for the given name: text, json, csv, tsv or markdown.
+func ParseFormat(name string) (Format, error) {
+	for f, n := range formatNames {
+		if n == name {
+			return f, nil
+		}
+	}
+	return Text, fmt.Errorf("unknown output format '%s'", name)
+}
+
+// Table - Results of the same type, one row per result.
+// Values are strings, ints, float64s or []float64s.
+type Table struct {
+	Type    string
+	Columns []string
+	Rows    [][]interface{}
+}
+
+// Writer - Writes tables to an io.Writer in the given format.
+type Writer struct {
+	w      io.Writer
+	format Format
+	tables int
+}
+
+// NewWriter - Returns a *output.Writer that writes tables to `w` in the given format.
+func NewWriter(w io.Writer, f Format) *Writer {
+	return &Writer{w: w, format: f}
+}
+
+// Format - Returns the format of the writer.
+func (w *Writer) Format() Format {
+	return w.format
+}
+
+// Write - Writes the table.
+func (w *Writer) Write(t Table) error {
+	var b bytes.Buffer
+	if w.tables > 0 && w.format != JSON {
+		b.WriteString("\n")
+	}
+	w.tables++
+	var err error
+	switch w.format {
+	case JSON:
+		err = writeJSON(&b, t)
+	case CSV, TSV:
+		cw := csv.NewWriter(&b)
+		if w.format == TSV {
+			cw.Comma = '\t'
+		}
+		cw.Write(t.Columns)
+		for _, row := range t.Rows {
+			cw.Write(formatRow(row))
+		}
+		cw.Flush()
+		err = cw.Error()
+	case Markdown:
+		writeMarkdown(&b, t)
+	default:
+		tw := tabwriter.NewWriter(&b, 0, 8, 2, ' ', 0)
+		fmt.Fprintf(tw, "%s\n", strings.Join(t.Columns, "\t"))
+		for _, row := range t.Rows {
+			fmt.Fprintf(tw, "%s\n", strings.Join(formatRow(row), "\t"))
+		}
+		err = tw.Flush()
+	}
+	if err != nil {
+		return err
+	}
+	_, err = w.w.Write(b.Bytes())
+	return err
+}
+
+// writeJSON - Writes the table as a JSON object on a single line, with the columns of each row in order.
+func writeJSON(b *bytes.Buffer, t Table) error {
+	b.WriteString(`{"type":`)
+	writeJSONValue(b, t.Type)
+	b.WriteString(`,"rows":[`)
+	for i, row := range t.Rows {
+		if i > 0 {
+			b.WriteString(",")
+		}
+		b.WriteString("{")
+		for j, column := range t.Columns {
+			if j > 0 {
+				b.WriteString(",")
+			}
+			writeJSONValue(b, column)
+			b.WriteString(":")
+			var value interface{}
+			if j < len(row) {
+				value = row[j]
+			}
+			err := writeJSONValue(b, value)
+			if err != nil {
+				return err
+			}
+		}
+		b.WriteString("}")
+	}
+	b.WriteString("]}\n")
+	return nil
+}
+
+// writeJSONValue - Writes the value as JSON, numbers that are NaN or infinite are null.
+func writeJSONValue(b *bytes.Buffer, value interface{}) error {
+	switch v := value.(type) {
+	case float64:
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			b.WriteString("null")
+			return nil
+		}
+	case []float64:
+		b.WriteString("[")
+		for i, x := range v {
+			if i > 0 {
+				b.WriteString(",")
+			}
+			writeJSONValue(b, x)
+		}
+		b.WriteString("]")
+		return nil
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	b.Write(data)
+	return nil
+}
+
+// writeMarkdown - Writes the table as a Markdown pipe table, numbers are right aligned.
+func writeMarkdown(b *bytes.Buffer, t Table) {
+	escape := strings.NewReplacer("|", `\|`, "\n", " ")
+	header := make([]string, len(t.Columns))
+	for j, column := range t.Columns {
+		header[j] = escape.Replace(column)
+	}
+	fmt.Fprintf(b, "| %s |\n", strings.Join(header, " | "))
+	align := make([]string, len(t.Columns))
+	for j := range t.Columns {
+		align[j] = "---"
+		if len(t.Rows) > 0 && j < len(t.Rows[0]) {
+			switch t.Rows[0][j].(type) {
+			case int, float64:
+				align[j] = "---:"
+			}
+		}
+	}
+	fmt.Fprintf(b, "| %s |\n", strings.Join(align, " | "))
+	for _, row := range t.Rows {
+		fields := formatRow(row)
+		for j := range fields {
+			fields[j] = escape.Replace(fields[j])
+		}
+		fmt.Fprintf(b, "| %s |\n", strings.Join(fields, " | "))
+	}
+}
+
+// formatRow - Returns the values of the row as strings.
+func formatRow(row []interface{}) []string {
+	fields := make([]string, len(row))
+	for i, value := range row {
+		fields[i] = formatValue(value)
+	}
+	return fields
+}
+
+// formatValue - Returns the value as a string, numbers that are NaN or infinite are empty.
+func formatValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return ""
+		}
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	case []float64:
+		values := make([]string, len(v))
+		for i, x := range v {
+			values[i] = formatValue(x)
+		}
+		return strings.Join(values, " ")
+	}
+	return fmt.Sprint(value)
+}
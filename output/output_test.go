@@ -0,0 +1,73 @@
+// This file is part of csv-analysis.
+//
+// Copyright (C) 2017  David Gamba Rios
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+package output
+
+import (
+	"bytes"
+	"math"
+	"testing"
+)
+
+func TestWrite(t *testing.T) {
+	tables := []Table{
+		{Type: "fits", Columns: []string{"name", "r2", "coefficients"}, Rows: [][]interface{}{
+			{"a|b", 0.5, []float64{1, 2.5}},
+			{"c", math.NaN(), []float64{}},
+		}},
+		{Type: "plots", Columns: []string{"title", "file"}, Rows: [][]interface{}{{"T", "t.png"}}},
+	}
+	tests := []struct {
+		format   Format
+		expected string
+	}{
+		{JSON, `{"type":"fits","rows":[{"name":"a|b","r2":0.5,"coefficients":[1,2.5]},{"name":"c","r2":null,"coefficients":[]}]}
+{"type":"plots","rows":[{"title":"T","file":"t.png"}]}
+`},
+		{CSV, "name,r2,coefficients\na|b,0.5,1 2.5\nc,,\n\ntitle,file\nT,t.png\n"},
+		{TSV, "name\tr2\tcoefficients\na|b\t0.5\t1 2.5\nc\t\t\n\ntitle\tfile\nT\tt.png\n"},
+		{Markdown, `| name | r2 | coefficients |
+| --- | ---: | --- |
+| a\|b | 0.5 | 1 2.5 |
+| c |  |  |
+
+| title | file |
+| --- | --- |
+| T | t.png |
+`},
+		{Text, "name  r2   coefficients\na|b   0.5  1 2.5\nc          \n\ntitle  file\nT      t.png\n"},
+	}
+	for _, test := range tests {
+		var buf bytes.Buffer
+		w := NewWriter(&buf, test.format)
+		for _, table := range tables {
+			err := w.Write(table)
+			if err != nil {
+				t.Fatalf("Unexpected error: %s\n", err)
+			}
+		}
+		if buf.String() != test.expected {
+			t.Errorf("Wrong %s output:\n%q\n!=\n%q\n", test.format, buf.String(), test.expected)
+		}
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	for _, name := range []string{"text", "json", "csv", "tsv", "markdown"} {
+		f, err := ParseFormat(name)
+		if err != nil {
+			t.Fatalf("Unexpected error: %s\n", err)
+		}
+		if f.String() != name {
+			t.Errorf("Wrong format: %s != %s\n", f, name)
+		}
+	}
+	_, err := ParseFormat("xml")
+	if err == nil || err.Error() != "unknown output format 'xml'" {
+		t.Errorf("Unexpected error: %v\n", err)
+	}
+}
@@ -0,0 +1,104 @@
+// This file is part of csv-analysis.
+//
+// Copyright (C) 2017  David Gamba Rios
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package regression
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/DavidGamba/csv-analysis/output"
+)
+
+// Fit - Regression solution, as reported to the user.
+type Fit struct {
+	// Kind of fit: "linear", the linear fit of the transformed data; "transformation", the fit of the original data through a linear transformation; or "polynomial".
+	Kind string
+	// Name of the linear transformation, empty for polynomial fits.
+	Transformation string
+	// Equation and its transformed linear equation, empty for polynomial fits.
+	Equation, TransformedEquation string
+	Degree                        int
+	// Coefficients, a and b for linear transformations, from the lowest degree up for polynomial fits.
+	Coefficients []float64
+	R2, SDev     float64
+	// R² and σ of the transformed data, NaN when they don't apply.
+	R2t, SDevt float64
+	// Plot file, if any.
+	Plot string
+}
+
+// LinearFit - Returns the linear fit of the transformed data.
+func (s Solution) LinearFit(p Plotter) Fit {
+	return Fit{
+		Kind:                "linear",
+		Transformation:      p.Name(),
+		Equation:            p.TextEquation(),
+		TransformedEquation: p.TextTransformedEquation(),
+		Degree:              1,
+		Coefficients:        []float64{s.At, s.Bt},
+		R2:                  s.R2t,
+		SDev:                s.SDevt,
+		R2t:                 math.NaN(),
+		SDevt:               math.NaN(),
+	}
+}
+
+// Fit - Returns the fit of the original data.
+func (s Solution) Fit(p Plotter) Fit {
+	return Fit{
+		Kind:                "transformation",
+		Transformation:      p.Name(),
+		Equation:            p.TextEquation(),
+		TransformedEquation: p.TextTransformedEquation(),
+		Degree:              1,
+		Coefficients:        []float64{s.A, s.B},
+		R2:                  s.R2,
+		SDev:                s.SDev,
+		R2t:                 s.R2t,
+		SDevt:               s.SDevt,
+	}
+}
+
+// Fit - Returns the polynomial fit.
+func (s PolynomialSolution) Fit() Fit {
+	f := Fit{Kind: "polynomial", Degree: s.Degree, R2: s.R2, SDev: s.SDev, R2t: math.NaN(), SDevt: math.NaN()}
+	if s.A != nil {
+		rows, _ := s.A.Dims()
+		for i := 0; i < rows; i++ {
+			f.Coefficients = append(f.Coefficients, s.A.At(i, 0))
+		}
+	}
+	return f
+}
+
+func (f Fit) String() string {
+	switch f.Kind {
+	case "linear":
+		return fmt.Sprintf("Linear   %-20s R²=%.4f σ=%.4f a=%10f b=%10f\n         %s -> %s",
+			f.Transformation, f.R2, f.SDev, f.Coefficients[0], f.Coefficients[1], f.Equation, f.TransformedEquation)
+	case "polynomial":
+		return fmt.Sprintf("Polynomial degree %d R²=%.4f σ=%.4f", f.Degree, f.R2, f.SDev)
+	}
+	return fmt.Sprintf("Equation %-20s R²t=%.4f R²=%.4f σ=%.4f σt=%.4f a=%10f b=%10f",
+		f.Equation, f.R2t, f.R2, f.SDev, f.SDevt, f.Coefficients[0], f.Coefficients[1])
+}
+
+// FitsTable - Returns the fits as a "fits" output table.
+func FitsTable(fits []Fit) output.Table {
+	t := output.Table{
+		Type: "fits",
+		Columns: []string{"kind", "transformation", "equation", "transformed_equation", "degree", "coefficients",
+			"r2", "sd", "r2t", "sdt", "plot"},
+	}
+	for _, f := range fits {
+		t.Rows = append(t.Rows, []interface{}{f.Kind, f.Transformation, f.Equation, f.TransformedEquation, f.Degree,
+			f.Coefficients, f.R2, f.SDev, f.R2t, f.SDevt, f.Plot})
+	}
+	return t
+}
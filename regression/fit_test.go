@@ -0,0 +1,51 @@
+// This file is part of csv-analysis.
+//
+// Copyright (C) 2017  David Gamba Rios
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+package regression
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFit(t *testing.T) {
+	x := []float64{1, 2, 3, 4}
+	y := []float64{3, 5, 7, 9}
+	s, err := SolveTransformation(x, y, &None{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %s\n", err)
+	}
+	tests := []struct {
+		fit      Fit
+		expected string
+	}{
+		{s.Fit(&None{}), "Equation y = a + bx           R²t=1.0000 R²=1.0000 σ=0.0000 σt=0.0000 a=  1.000000 b=  2.000000"},
+		{s.LinearFit(&None{}), "Linear   No Transformation    R²=1.0000 σ=0.0000 a=  1.000000 b=  2.000000\n         y = a + bx -> y = a + bx"},
+	}
+	for _, test := range tests {
+		if test.fit.String() != test.expected {
+			t.Errorf("Wrong fit:\n%s\n!=\n%s\n", test.fit, test.expected)
+		}
+	}
+
+	p, err := SolvePolynomial(x, y, 1)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s\n", err)
+	}
+	f := p.Fit()
+	if f.String() != "Polynomial degree 1 R²=1.0000 σ=0.0000" {
+		t.Errorf("Wrong fit: %s\n", f)
+	}
+	table := FitsTable([]Fit{f})
+	if len(table.Rows) != 1 || len(table.Rows[0]) != len(table.Columns) {
+		t.Fatalf("Wrong table: %v\n", table)
+	}
+	coefficients := table.Rows[0][5].([]float64)
+	if len(coefficients) != 2 || !reflect.DeepEqual(coefficients, f.Coefficients) {
+		t.Errorf("Wrong coefficients: %v\n", coefficients)
+	}
+}
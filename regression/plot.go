@@ -14,10 +14,13 @@ package regression
 import (
 	"fmt"
 	"image/color"
+	"math"
 	"regexp"
+	"sort"
 	"strings"
 
 	"gonum.org/v1/plot"
+	"gonum.org/v1/plot/palette/moreland"
 	"gonum.org/v1/plot/plotter"
 	"gonum.org/v1/plot/vg"
 )
@@ -86,12 +89,26 @@ func getColor(i int) color.RGBA {
 	return colorList[j]
 }
 
-// PlotRegression -
+// PlotRegression - Plots the data and the regression function and prints the name of the PNG file.
 func PlotRegression(x []float64, ys [][]float64, f func(float64) float64, r2, sDev float64, ps PlotSettings) error {
-	p, err := NewPlot(ps)
+	return printPlotName(PlotRegressionFile(x, ys, f, r2, sDev, ps))
+}
+
+// printPlotName - Prints the name of the PNG file of a plot, unless there is an error.
+func printPlotName(name string, err error) error {
 	if err != nil {
 		return err
 	}
+	fmt.Printf("Plot: %s\n", name)
+	return nil
+}
+
+// PlotRegressionFile - Plots the data and the regression function, returns the name of the PNG file.
+func PlotRegressionFile(x []float64, ys [][]float64, f func(float64) float64, r2, sDev float64, ps PlotSettings) (string, error) {
+	p, err := NewPlot(ps)
+	if err != nil {
+		return "", err
+	}
 	// Plot in reverse order because the first entry is the most important
 	last := len(ys) - 1
 	for index := range ys {
@@ -104,7 +121,7 @@ func PlotRegression(x []float64, ys [][]float64, f func(float64) float64, r2, sD
 		}
 		lpLine, lpPoints, err := plotter.NewLinePoints(pts)
 		if err != nil {
-			return err
+			return "", err
 		}
 		lpLine.Color = getColor(i)
 		lpPoints.Color = getColor(i)
@@ -124,57 +141,227 @@ func PlotRegression(x []float64, ys [][]float64, f func(float64) float64, r2, sD
 	name := "plot-" + filenameClean(ps.Title) + ".png"
 	// Save the plot to a PNG file.
 	if err := p.Save(8*vg.Inch, 8*vg.Inch, name); err != nil {
-		return err
+		return "", err
 	}
-	fmt.Printf("Plot: %s\n", name)
-
-	return nil
+	return name, nil
 }
 
-// PlotTimeData -
+// PlotTimeData - Plots each Y over a time X axis and prints the name of the PNG file.
 func PlotTimeData(x []float64, ys [][]float64, ps PlotSettings) error {
+	return printPlotName(PlotTimeDataFile(x, ys, ps))
+}
+
+// PlotTimeDataFile - Plots each Y over a time X axis, returns the name of the PNG file.
+func PlotTimeDataFile(x []float64, ys [][]float64, ps PlotSettings) (string, error) {
+	series := make([]Series, len(ys))
+	for i, y := range ys {
+		series[i] = Series{X: x, Y: y, Label: fmt.Sprintf("%s %d", ps.DataLabel, i)}
+	}
+	return PlotTimeSeries(series, ps)
+}
+
+// Series - Data points of a plot line with the label shown in the legend.
+type Series struct {
+	X, Y  []float64
+	Label string
+}
+
+// PlotTimeSeries - Plots each series as a line over a time X axis, returns the name of the PNG file.
+// Unlike PlotTimeData, every series has its own X values, for example one series per file.
+func PlotTimeSeries(series []Series, ps PlotSettings) (string, error) {
 	p, err := NewPlot(ps)
 	if err != nil {
-		return err
+		return "", err
 	}
 	p.X.Tick.Marker = plot.TimeTicks{}
 	// Plot in reverse order because the first entry is the most important
-	last := len(ys) - 1
-	for index := range ys {
+	last := len(series) - 1
+	for index := range series {
 		i := last - index
-		y := ys[i]
-		pts := make(plotter.XYs, len(x))
-		for j := range x {
-			pts[j].X = x[j]
-			pts[j].Y = y[j]
+		s := series[i]
+		pts := make(plotter.XYs, len(s.X))
+		for j := range s.X {
+			pts[j].X = s.X[j]
+			pts[j].Y = s.Y[j]
 		}
 		lpLine, _, err := plotter.NewLinePoints(pts)
 		if err != nil {
-			return err
+			return "", err
 		}
 		lpLine.Color = getColor(i)
 		if i == 0 && ps.Bold {
 			lpLine.Width = 2
 		}
 		p.Add(lpLine)
-		p.Legend.Add(fmt.Sprintf("%s %d", ps.DataLabel, i), lpLine)
+		p.Legend.Add(s.Label, lpLine)
 	}
 	// Save the plot to a PNG file.
 	name := "plot-" + filenameClean(ps.Title) + ".png"
 	// 6, 3.5
 	if err := p.Save(12*vg.Inch, 7*vg.Inch, name); err != nil {
-		return err
+		return "", err
 	}
-	fmt.Printf("Plot: %s\n", name)
+	return name, nil
+}
+
+// grid - Square matrix of values as a plotter.GridXYZ, the cell of row r and column c is at X c and Y r.
+type grid [][]float64
 
+func (g grid) Dims() (c, r int)   { return len(g), len(g) }
+func (g grid) Z(c, r int) float64 { return g[r][c] }
+func (g grid) X(c int) float64    { return float64(c) }
+func (g grid) Y(r int) float64    { return float64(r) }
+
+// PlotHeatMap - Plots the square matrix of values, like a correlation matrix, as a heat map labeled with the names of its rows and columns, returns the name of the PNG file.
+// The colors go from blue at min to red at max, each cell shows its value and NaN values are left blank.
+func PlotHeatMap(names []string, values [][]float64, min, max float64, ps PlotSettings) (string, error) {
+	if len(values) < 2 {
+		return "", fmt.Errorf("heat map needs at least 2 rows, got %d", len(values))
+	}
+	p, err := NewPlot(ps)
+	if err != nil {
+		return "", err
+	}
+	h := plotter.NewHeatMap(grid(values), moreland.SmoothBlueRed().Palette(255))
+	h.Min, h.Max = min, max
+	p.Add(h)
+	var labels plotter.XYLabels
+	for r, row := range values {
+		for c, v := range row {
+			if math.IsNaN(v) {
+				continue
+			}
+			labels.XYs = append(labels.XYs, struct{ X, Y float64 }{float64(c), float64(r)})
+			labels.Labels = append(labels.Labels, fmt.Sprintf("%.2f", v))
+		}
+	}
+	l, err := plotter.NewLabels(labels)
+	if err != nil {
+		return "", err
+	}
+	p.Add(l)
+	p.NominalX(names...)
+	p.NominalY(names...)
+
+	name := "plot-" + filenameClean(ps.Title) + ".png"
+	size := vg.Length(len(names)) * vg.Inch
+	if size < 6*vg.Inch {
+		size = 6 * vg.Inch
+	}
+	if err := p.Save(size, size, name); err != nil {
+		return "", err
+	}
+	return name, nil
+}
+
+// Marker - Vertical line drawn at X, like a percentile, with its label in the legend.
+type Marker struct {
+	X     float64
+	Label string
+}
+
+// addMarkers - Adds the markers as dashed vertical lines from 0 to top.
+func addMarkers(p *plot.Plot, markers []Marker, top float64) error {
+	for i, m := range markers {
+		l, err := plotter.NewLine(plotter.XYs{{X: m.X, Y: 0}, {X: m.X, Y: top}})
+		if err != nil {
+			return err
+		}
+		l.Color = getColor(i + 1)
+		l.Dashes = []vg.Length{vg.Points(4), vg.Points(2)}
+		p.Add(l)
+		p.Legend.Add(m.Label, l)
+	}
 	return nil
 }
 
-// PlotLinearTransformation -
+// PlotHistogram - Plots the histogram of the data with the given number of equal width bins, normalized so its area is 1, and the markers, returns the name of the PNG file.
+// The density function, like a kernel density estimate, is drawn over it when given.
+func PlotHistogram(data []float64, bins int, density func(float64) float64, markers []Marker, ps PlotSettings) (string, error) {
+	p, err := NewPlot(ps)
+	if err != nil {
+		return "", err
+	}
+	h, err := plotter.NewHist(plotter.Values(data), bins)
+	if err != nil {
+		return "", err
+	}
+	h.Normalize(1)
+	p.Add(h)
+	top := 0.0
+	for _, b := range h.Bins {
+		top = math.Max(top, b.Weight)
+	}
+	if density != nil {
+		f := plotter.NewFunction(density)
+		f.Samples = 200
+		f.Color = getColor(0)
+		f.Width = 2
+		p.Add(f)
+		p.Legend.Add("KDE", f)
+		min, max := data[0], data[0]
+		for _, x := range data {
+			min, max = math.Min(min, x), math.Max(max, x)
+		}
+		for i := 0; i <= 200; i++ {
+			top = math.Max(top, density(min+float64(i)*(max-min)/200))
+		}
+	}
+	err = addMarkers(p, markers, top)
+	if err != nil {
+		return "", err
+	}
+
+	name := "plot-" + filenameClean(ps.Title) + ".png"
+	if err := p.Save(12*vg.Inch, 7*vg.Inch, name); err != nil {
+		return "", err
+	}
+	return name, nil
+}
+
+// PlotECDF - Plots the empirical cumulative distribution function of the data, the proportion of values less than or equal to x, and the markers, returns the name of the PNG file.
+func PlotECDF(data []float64, markers []Marker, ps PlotSettings) (string, error) {
+	p, err := NewPlot(ps)
+	if err != nil {
+		return "", err
+	}
+	sorted := append([]float64{}, data...)
+	sort.Float64s(sorted)
+	n := float64(len(sorted))
+	// A step per value, up from the proportion before it.
+	pts := make(plotter.XYs, 0, 2*len(sorted))
+	for i, x := range sorted {
+		pts = append(pts, struct{ X, Y float64 }{x, float64(i) / n}, struct{ X, Y float64 }{x, float64(i+1) / n})
+	}
+	l, err := plotter.NewLine(pts)
+	if err != nil {
+		return "", err
+	}
+	l.Color = getColor(0)
+	p.Add(l)
+	p.Legend.Add("ECDF", l)
+	err = addMarkers(p, markers, 1)
+	if err != nil {
+		return "", err
+	}
+
+	name := "plot-" + filenameClean(ps.Title) + ".png"
+	if err := p.Save(12*vg.Inch, 7*vg.Inch, name); err != nil {
+		return "", err
+	}
+	return name, nil
+}
+
+// PlotLinearTransformation - Prints the linear fit of the transformed data, plots it and prints the name of the PNG file.
 func (s Solution) PlotLinearTransformation(p Plotter) error {
 	fmt.Printf("Linear   %-20s R²=%.4f σ=%.4f a=%10f b=%10f\n", p.Name(), s.R2t, s.SDevt, s.At, s.Bt)
 	fmt.Printf("         %s -> %s\n", p.TextEquation(), p.TextTransformedEquation())
-	return PlotRegression(s.Xt, [][]float64{s.Yt}, s.LinearFunction(), s.R2t, s.SDevt, PlotSettings{
+	return printPlotName(s.PlotLinearTransformationFile(p))
+}
+
+// PlotLinearTransformationFile - Plots the transformed data, see LinearFit, returns the name of the PNG file.
+func (s Solution) PlotLinearTransformationFile(p Plotter) (string, error) {
+	return PlotRegressionFile(s.Xt, [][]float64{s.Yt}, s.LinearFunction(), s.R2t, s.SDevt, PlotSettings{
 		Title:     "Linear " + p.Name(),
 		XLabel:    p.XLabel(),
 		YLabel:    p.YLabel(),
@@ -182,10 +369,15 @@ func (s Solution) PlotLinearTransformation(p Plotter) error {
 	})
 }
 
-// Plot -
+// Plot - Prints the fit of the original data, plots it and prints the name of the PNG file.
 func (s Solution) Plot(p Plotter) error {
 	fmt.Printf("Equation %-20s R²t=%.4f R²=%.4f σ=%.4f σt=%.4f a=%10f b=%10f\n", p.TextEquation(), s.R2t, s.R2, s.SDev, s.SDevt, s.A, s.B)
-	return PlotRegression(s.X, [][]float64{s.Y}, s.RegressionFunction(), s.R2, s.SDev, PlotSettings{
+	return printPlotName(s.PlotFile(p))
+}
+
+// PlotFile - Plots the original data, see Fit, returns the name of the PNG file.
+func (s Solution) PlotFile(p Plotter) (string, error) {
+	return PlotRegressionFile(s.X, [][]float64{s.Y}, s.RegressionFunction(), s.R2, s.SDev, PlotSettings{
 		Title:     p.Name(),
 		XLabel:    "X",
 		YLabel:    "Y",
@@ -193,10 +385,15 @@ func (s Solution) Plot(p Plotter) error {
 	})
 }
 
-// Plot -
+// Plot - Prints the fit of the data, plots it and prints the name of the PNG file.
 func (s PolynomialSolution) Plot() error {
 	fmt.Printf("Polynomial degree %d R²=%.4f σ=%.4f\n", s.Degree, s.R2, s.SDev)
-	return PlotRegression(s.X, [][]float64{s.Y}, s.PolynomialFunction(), s.R2, s.SDev, PlotSettings{
+	return printPlotName(s.PlotFile())
+}
+
+// PlotFile - Plots the data, see Fit, returns the name of the PNG file.
+func (s PolynomialSolution) PlotFile() (string, error) {
+	return PlotRegressionFile(s.X, [][]float64{s.Y}, s.PolynomialFunction(), s.R2, s.SDev, PlotSettings{
 		Title:     "Polynomial Regression",
 		XLabel:    "X",
 		YLabel:    "Y",
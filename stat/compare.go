@@ -0,0 +1,326 @@
+// This file is part of csv-analysis.
+//
+// Copyright (C) 2017  David Gamba Rios
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package stat
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"gonum.org/v1/gonum/stat/distuv"
+)
+
+// TestResult - Result of a hypothesis test.
+type TestResult struct {
+	// Name of the dataset compared to the baseline, "all" for tests of all the datasets.
+	Dataset string
+	// Name of the test: "welch-t", "mann-whitney-u", "ks", "anova" or "kruskal-wallis".
+	Test      string
+	Statistic float64
+	// Degrees of freedom, NaN when the test doesn't have them.
+	// DF2 is the second degrees of freedom of the F distribution, NaN for the other tests.
+	DF, DF2 float64
+	// Two-sided p-value.
+	P float64
+	// Name of the effect size: "cohen-d", "rank-biserial", "ks-d", "eta-squared" or "epsilon-squared".
+	Effect     string
+	EffectSize float64
+}
+
+// Difference - Difference of a statistic between a dataset and the baseline.
+type Difference struct {
+	// Name of the dataset compared to the baseline.
+	Dataset string
+	// Name of the statistic, see Summary.Names.
+	Stat            string
+	Baseline, Value float64
+	// Value - Baseline.
+	Delta float64
+	// Delta relative to the baseline, NaN when the baseline is zero.
+	Relative float64
+}
+
+// Comparison - Differences and hypothesis tests of datasets against the first one, the baseline.
+type Comparison struct {
+	Baseline    string
+	Differences []Difference
+	// Tests of each dataset against the baseline followed, with more than two datasets, by the tests of all of them.
+	Tests []TestResult
+}
+
+// Compare - Compares each dataset with the first one, the baseline.
+// It reports the difference of the mean, the median and the percentiles of the options, without p50 which is the median,
+// Welch's t-test, the Mann-Whitney U test and the Kolmogorov-Smirnov test.
+// With more than two datasets it also reports the one-way ANOVA and the Kruskal-Wallis test of all of them.
+func Compare(names []string, data [][]float64, o Options) (*Comparison, error) {
+	if len(data) < 2 {
+		return nil, fmt.Errorf("compare needs at least 2 datasets, got %d", len(data))
+	}
+	for i, d := range data {
+		if len(d) == 0 {
+			return nil, fmt.Errorf("dataset '%s' has no data", names[i])
+		}
+	}
+	o.Level, o.Bootstrap = 0, nil
+	base, err := Describe(data[0], o)
+	if err != nil {
+		return nil, err
+	}
+	stats := []string{"mean", "median"}
+	for _, p := range o.Percentiles {
+		if p != 50 {
+			stats = append(stats, percentileName(p))
+		}
+	}
+	c := &Comparison{Baseline: names[0]}
+	for i := 1; i < len(data); i++ {
+		s, err := Describe(data[i], o)
+		if err != nil {
+			return nil, err
+		}
+		for _, name := range stats {
+			b, _ := base.Stat(name)
+			x, _ := s.Stat(name)
+			d := Difference{Dataset: names[i], Stat: name, Baseline: b, Value: x, Delta: x - b, Relative: math.NaN()}
+			if b != 0 {
+				d.Relative = d.Delta / math.Abs(b)
+			}
+			c.Differences = append(c.Differences, d)
+		}
+		for _, t := range []TestResult{
+			WelchT(data[0], data[i]),
+			MannWhitneyU(data[0], data[i]),
+			KolmogorovSmirnov(data[0], data[i]),
+		} {
+			t.Dataset = names[i]
+			c.Tests = append(c.Tests, t)
+		}
+	}
+	if len(data) > 2 {
+		for _, t := range []TestResult{OneWayANOVA(data), KruskalWallis(data)} {
+			t.Dataset = "all"
+			c.Tests = append(c.Tests, t)
+		}
+	}
+	return c, nil
+}
+
+// newTestResult - Returns a result of the named test with NaN values.
+func newTestResult(test, effect string) TestResult {
+	nan := math.NaN()
+	return TestResult{Test: test, Statistic: nan, DF: nan, DF2: nan, P: nan, Effect: effect, EffectSize: nan}
+}
+
+// WelchT - Returns Welch's t-test of the difference of the means of b and a, which doesn't assume equal variances.
+// The effect size is Cohen's d, the difference of the means over the pooled standard deviation.
+// The values are NaN with less than 2 values in a dataset or when both have no variance.
+func WelchT(a, b []float64) TestResult {
+	r := newTestResult("welch-t", "cohen-d")
+	na, nb := float64(len(a)), float64(len(b))
+	if na < 2 || nb < 2 {
+		return r
+	}
+	ma, sa := meanSD(a, 1)
+	mb, sb := meanSD(b, 1)
+	va, vb := sa*sa/na, sb*sb/nb
+	if va+vb == 0 {
+		return r
+	}
+	r.Statistic = (mb - ma) / math.Sqrt(va+vb)
+	r.DF = (va + vb) * (va + vb) / (va*va/(na-1) + vb*vb/(nb-1))
+	r.P = 2 * distuv.StudentsT{Mu: 0, Sigma: 1, Nu: r.DF}.Survival(math.Abs(r.Statistic))
+	pooled := math.Sqrt(((na-1)*sa*sa + (nb-1)*sb*sb) / (na + nb - 2))
+	r.EffectSize = (mb - ma) / pooled
+	return r
+}
+
+// MannWhitneyU - Returns the Mann-Whitney U test, also known as the Wilcoxon rank-sum test, of b against a.
+// The statistic is the U of b, the number of pairs where the value of b is bigger plus half the ties.
+// The p-value uses the normal approximation with continuity and ties corrections, so it is approximate for small samples.
+// The effect size is the rank-biserial correlation, 2U / (na * nb) - 1, positive when b tends to be bigger.
+func MannWhitneyU(a, b []float64) TestResult {
+	r := newTestResult("mann-whitney-u", "rank-biserial")
+	if len(a) == 0 || len(b) == 0 {
+		return r
+	}
+	ranks, ties := rank([][]float64{a, b})
+	na, nb := float64(len(a)), float64(len(b))
+	n := na + nb
+	var sum float64
+	for _, x := range ranks[1] {
+		sum += x
+	}
+	u := sum - nb*(nb+1)/2
+	r.Statistic = u
+	r.EffectSize = 2*u/(na*nb) - 1
+	sigma := math.Sqrt(na * nb / 12 * ((n + 1) - ties/(n*(n-1))))
+	if sigma == 0 || math.IsNaN(sigma) {
+		return r
+	}
+	z := (math.Abs(u-na*nb/2) - 0.5) / sigma
+	if z < 0 {
+		z = 0
+	}
+	r.P = 2 * distuv.UnitNormal.Survival(z)
+	return r
+}
+
+// KolmogorovSmirnov - Returns the two-sample Kolmogorov-Smirnov test of a and b, D is the largest difference between their empirical distribution functions.
+// The p-value uses the asymptotic Kolmogorov distribution, with Stephens' correction for small samples.
+// The effect size is D itself.
+func KolmogorovSmirnov(a, b []float64) TestResult {
+	r := newTestResult("ks", "ks-d")
+	if len(a) == 0 || len(b) == 0 {
+		return r
+	}
+	sa, sb := sortedCopy(a), sortedCopy(b)
+	na, nb := float64(len(sa)), float64(len(sb))
+	var d float64
+	i, j := 0, 0
+	for i < len(sa) && j < len(sb) {
+		x := math.Min(sa[i], sb[j])
+		for i < len(sa) && sa[i] == x {
+			i++
+		}
+		for j < len(sb) && sb[j] == x {
+			j++
+		}
+		d = math.Max(d, math.Abs(float64(i)/na-float64(j)/nb))
+	}
+	r.Statistic, r.EffectSize = d, d
+	en := math.Sqrt(na * nb / (na + nb))
+	r.P = kolmogorovSurvival((en + 0.12 + 0.11/en) * d)
+	return r
+}
+
+// kolmogorovSurvival - Returns P(K > lambda) for the Kolmogorov distribution.
+func kolmogorovSurvival(lambda float64) float64 {
+	if lambda < 0.2 {
+		return 1
+	}
+	var sum float64
+	sign := 1.0
+	for k := 1.0; k <= 100; k++ {
+		term := sign * math.Exp(-2*k*k*lambda*lambda)
+		sum += term
+		if math.Abs(term) < 1e-12 {
+			break
+		}
+		sign = -sign
+	}
+	return math.Max(0, math.Min(1, 2*sum))
+}
+
+// OneWayANOVA - Returns the one-way analysis of variance F test of the means of the groups, which assumes normal distributions with equal variances.
+// The effect size is eta squared, the proportion of the variance explained by the groups.
+// The values are NaN with less than 2 groups, no more values than groups or no variance within the groups.
+func OneWayANOVA(groups [][]float64) TestResult {
+	r := newTestResult("anova", "eta-squared")
+	k := float64(len(groups))
+	var n, total float64
+	for _, g := range groups {
+		n += float64(len(g))
+		for _, x := range g {
+			total += x
+		}
+	}
+	if k < 2 || n <= k {
+		return r
+	}
+	grand := total / n
+	var between, within float64
+	for _, g := range groups {
+		if len(g) == 0 {
+			continue
+		}
+		m := mean(g)
+		between += float64(len(g)) * (m - grand) * (m - grand)
+		for _, x := range g {
+			within += (x - m) * (x - m)
+		}
+	}
+	if within == 0 {
+		return r
+	}
+	r.DF, r.DF2 = k-1, n-k
+	r.Statistic = (between / r.DF) / (within / r.DF2)
+	r.P = distuv.F{D1: r.DF, D2: r.DF2}.Survival(r.Statistic)
+	r.EffectSize = between / (between + within)
+	return r
+}
+
+// KruskalWallis - Returns the Kruskal-Wallis H test of the groups, the rank based alternative to the one-way ANOVA, with the ties correction.
+// The p-value uses the chi-squared approximation with k - 1 degrees of freedom.
+// The effect size is epsilon squared, H / (n - 1).
+// The values are NaN with less than 2 groups or when all the values are equal.
+func KruskalWallis(groups [][]float64) TestResult {
+	r := newTestResult("kruskal-wallis", "epsilon-squared")
+	var n float64
+	for _, g := range groups {
+		n += float64(len(g))
+	}
+	if len(groups) < 2 || n < 2 {
+		return r
+	}
+	ranks, ties := rank(groups)
+	correction := 1 - ties/(n*n*n-n)
+	if correction == 0 {
+		return r
+	}
+	var h float64
+	for _, g := range ranks {
+		if len(g) == 0 {
+			continue
+		}
+		var sum float64
+		for _, x := range g {
+			sum += x
+		}
+		h += sum * sum / float64(len(g))
+	}
+	h = (12/(n*(n+1))*h - 3*(n+1)) / correction
+	r.Statistic = h
+	r.DF = float64(len(groups) - 1)
+	r.P = distuv.ChiSquared{K: r.DF}.Survival(h)
+	r.EffectSize = h / (n - 1)
+	return r
+}
+
+// rank - Returns the 1-based ranks of the values of the groups ranked together, ties get the average of their ranks, and the sum of t^3 - t over the groups of t tied values.
+func rank(groups [][]float64) ([][]float64, float64) {
+	type value struct {
+		x    float64
+		g, i int
+	}
+	var values []value
+	ranks := make([][]float64, len(groups))
+	for g, data := range groups {
+		ranks[g] = make([]float64, len(data))
+		for i, x := range data {
+			values = append(values, value{x, g, i})
+		}
+	}
+	sort.Slice(values, func(i, j int) bool { return values[i].x < values[j].x })
+	var ties float64
+	for i := 0; i < len(values); {
+		j := i
+		for j < len(values) && values[j].x == values[i].x {
+			j++
+		}
+		// Values i to j-1 have ranks i+1 to j.
+		r := float64(i+1+j) / 2
+		for _, v := range values[i:j] {
+			ranks[v.g][v.i] = r
+		}
+		t := float64(j - i)
+		ties += t*t*t - t
+		i = j
+	}
+	return ranks, ties
+}
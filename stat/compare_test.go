@@ -0,0 +1,81 @@
+// This file is part of csv-analysis.
+//
+// Copyright (C) 2017  David Gamba Rios
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+package stat
+
+import (
+	"math"
+	"testing"
+)
+
+var (
+	compareA = []float64{1, 2, 3, 4, 5}
+	compareB = []float64{3, 4, 5, 6, 7}
+	compareC = []float64{2, 4, 6, 8, 10}
+)
+
+func TestTests(t *testing.T) {
+	tests := []struct {
+		result                    TestResult
+		statistic, df, p, effect float64
+	}{
+		{WelchT(compareA, compareB), 2, 8, 0.080516, 2 / math.Sqrt(2.5)},
+		// Ranks of B: 3.5, 5.5, 7.5, 9 and 10.
+		{MannWhitneyU(compareA, compareB), 20.5, math.NaN(), 0.113846, 0.64},
+		{KolmogorovSmirnov(compareA, compareB), 0.4, math.NaN(), 0.697405, 0.4},
+		{OneWayANOVA([][]float64{compareA, compareB, compareC}), 7.0 / 3, 2, 0.139314, 0.28},
+		{KruskalWallis([][]float64{compareA, compareB, compareC}), 3.758696, 2, 0.152690, 3.758696 / 14},
+	}
+	for _, test := range tests {
+		r := test.result
+		if math.Abs(r.Statistic-test.statistic) > 1e-5 || !closeFloat(r.DF, test.df) ||
+			math.Abs(r.P-test.p) > 1e-5 || math.Abs(r.EffectSize-test.effect) > 1e-5 {
+			t.Errorf("Wrong %s: %+v\n", r.Test, r)
+		}
+	}
+	if r := OneWayANOVA([][]float64{compareA, compareB, compareC}); r.DF2 != 12 {
+		t.Errorf("Wrong anova DF2: %v != 12\n", r.DF2)
+	}
+	r := WelchT([]float64{1, 1}, []float64{1, 1})
+	if !math.IsNaN(r.Statistic) || !math.IsNaN(r.P) {
+		t.Errorf("Wrong welch-t without variance: %+v\n", r)
+	}
+}
+
+func TestCompare(t *testing.T) {
+	o := DefaultOptions()
+	o.Percentiles = []float64{50, 90}
+	c, err := Compare([]string{"a", "b", "c"}, [][]float64{compareA, compareB, compareC}, o)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s\n", err)
+	}
+	if c.Baseline != "a" || len(c.Differences) != 6 || len(c.Tests) != 8 {
+		t.Fatalf("Wrong comparison: %+v\n", c)
+	}
+	d := c.Differences[0]
+	if d.Dataset != "b" || d.Stat != "mean" || d.Baseline != 3 || d.Value != 5 || d.Delta != 2 || math.Abs(d.Relative-2.0/3) > 1e-9 {
+		t.Errorf("Wrong difference: %+v\n", d)
+	}
+	if c.Differences[2].Stat != "p90" {
+		t.Errorf("Wrong difference: %+v\n", c.Differences[2])
+	}
+	if c.Tests[3].Dataset != "c" || c.Tests[6].Dataset != "all" || c.Tests[6].Test != "anova" {
+		t.Errorf("Wrong tests: %+v\n", c.Tests)
+	}
+	_, err = Compare([]string{"a"}, [][]float64{compareA}, o)
+	if err == nil {
+		t.Errorf("Expected error with a single dataset\n")
+	}
+	_, err = Compare([]string{"a", "b"}, [][]float64{compareA, nil}, o)
+	if err == nil || err.Error() != "dataset 'b' has no data" {
+		t.Errorf("Wrong error: %v\n", err)
+	}
+}
+
+func closeFloat(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9 || math.IsNaN(a) && math.IsNaN(b)
+}
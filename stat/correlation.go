@@ -0,0 +1,296 @@
+// This file is part of csv-analysis.
+//
+// Copyright (C) 2017  David Gamba Rios
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package stat
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// CorrelationMethod - Correlation coefficient.
+type CorrelationMethod int
+
+const (
+	// Pearson - Pearson's product-moment correlation, the strength of the linear relationship.
+	Pearson CorrelationMethod = iota
+	// Spearman - Spearman's rank correlation, the Pearson correlation of the ranks, the strength of any monotonic relationship.
+	Spearman
+	// Kendall - Kendall's tau-b rank correlation, based on the concordant and discordant pairs, adjusted for ties.
+	Kendall
+)
+
+var correlationMethodNames = map[CorrelationMethod]string{
+	Pearson:  "pearson",
+	Spearman: "spearman",
+	Kendall:  "kendall",
+}
+
+func (m CorrelationMethod) String() string {
+	return correlationMethodNames[m]
+}
+
+// ParseCorrelationMethod - Returns the CorrelationMethod for the given name: pearson, spearman or kendall.
+func ParseCorrelationMethod(name string) (CorrelationMethod, error) {
+	for m, n := range correlationMethodNames {
+		if n == name {
+			return m, nil
+		}
+	}
+	return Pearson, fmt.Errorf("unknown correlation method '%s'", name)
+}
+
+// Correlation - Correlation coefficients and covariance of two columns.
+// The coefficients are NaN when a column has less than 2 values or no variance.
+type Correlation struct {
+	Pearson, Spearman, Kendall float64
+	// Sample covariance.
+	Covariance float64
+}
+
+// Coefficient - Returns the coefficient of the given method.
+func (c Correlation) Coefficient(m CorrelationMethod) float64 {
+	switch m {
+	case Spearman:
+		return c.Spearman
+	case Kendall:
+		return c.Kendall
+	}
+	return c.Pearson
+}
+
+// Correlate - Returns the correlation coefficients and the covariance of x and y, which must have the same length.
+func Correlate(x, y []float64) Correlation {
+	rx, _ := rank([][]float64{x})
+	ry, _ := rank([][]float64{y})
+	return Correlation{
+		Pearson:    PearsonCorrelation(x, y),
+		Spearman:   PearsonCorrelation(rx[0], ry[0]),
+		Kendall:    KendallTau(x, y),
+		Covariance: Covariance(x, y),
+	}
+}
+
+// Covariance - Returns the sample covariance of x and y, NaN with less than 2 values.
+func Covariance(x, y []float64) float64 {
+	n := len(x)
+	if n < 2 {
+		return math.NaN()
+	}
+	mx, my := mean(x), mean(y)
+	var sum float64
+	for i := range x {
+		sum += (x[i] - mx) * (y[i] - my)
+	}
+	return sum / float64(n-1)
+}
+
+// PearsonCorrelation - Returns Pearson's correlation coefficient of x and y.
+// Returns NaN with less than 2 values or when x or y have no variance.
+func PearsonCorrelation(x, y []float64) float64 {
+	if len(x) < 2 {
+		return math.NaN()
+	}
+	mx, my := mean(x), mean(y)
+	var sxy, sxx, syy float64
+	for i := range x {
+		dx, dy := x[i]-mx, y[i]-my
+		sxy += dx * dy
+		sxx += dx * dx
+		syy += dy * dy
+	}
+	if sxx == 0 || syy == 0 {
+		return math.NaN()
+	}
+	return sxy / math.Sqrt(sxx*syy)
+}
+
+// KendallTau - Returns Kendall's tau-b of x and y, with Knight's O(n log n) algorithm.
+// Returns NaN with less than 2 values or when x or y have no variance.
+func KendallTau(x, y []float64) float64 {
+	n := len(x)
+	if n < 2 {
+		return math.NaN()
+	}
+	order := indexes(n)
+	sort.Slice(order, func(i, j int) bool {
+		a, b := order[i], order[j]
+		if x[a] != x[b] {
+			return x[a] < x[b]
+		}
+		return y[a] < y[b]
+	})
+	// xTies are the pairs tied in x and jointTies the pairs tied in both.
+	var xTies, jointTies float64
+	for i := 0; i < n; {
+		j := i
+		for j < n && x[order[j]] == x[order[i]] {
+			j++
+		}
+		xTies += tiedPairs(j - i)
+		for k := i; k < j; {
+			l := k
+			for l < j && y[order[l]] == y[order[k]] {
+				l++
+			}
+			jointTies += tiedPairs(l - k)
+			k = l
+		}
+		i = j
+	}
+	ys := make([]float64, n)
+	for i, j := range order {
+		ys[i] = y[j]
+	}
+	// Sorting y by x order, each swap is a discordant pair.
+	swaps := float64(mergeSortSwaps(ys, make([]float64, n)))
+	var yTies float64
+	for i := 0; i < n; {
+		j := i
+		for j < n && ys[j] == ys[i] {
+			j++
+		}
+		yTies += tiedPairs(j - i)
+		i = j
+	}
+	pairs := tiedPairs(n)
+	d := math.Sqrt((pairs - xTies) * (pairs - yTies))
+	if d == 0 {
+		return math.NaN()
+	}
+	return (pairs - xTies - yTies + jointTies - 2*swaps) / d
+}
+
+// tiedPairs - Returns the number of pairs of n values, n * (n - 1) / 2.
+func tiedPairs(n int) float64 {
+	return float64(n) * float64(n-1) / 2
+}
+
+// mergeSortSwaps - Sorts the data in place, using buf of the same length, and returns the number of pairs out of order, the inversions.
+func mergeSortSwaps(data, buf []float64) int {
+	n := len(data)
+	if n < 2 {
+		return 0
+	}
+	m := n / 2
+	swaps := mergeSortSwaps(data[:m], buf[:m]) + mergeSortSwaps(data[m:], buf[m:])
+	i, j, k := 0, m, 0
+	for i < m && j < n {
+		if data[j] < data[i] {
+			buf[k] = data[j]
+			swaps += m - i
+			j++
+		} else {
+			buf[k] = data[i]
+			i++
+		}
+		k++
+	}
+	k += copy(buf[k:], data[i:m])
+	copy(buf[k:], data[j:])
+	copy(data, buf)
+	return swaps
+}
+
+// CorrelationMatrix - Correlations between each pair of columns.
+type CorrelationMatrix struct {
+	Names []string
+	// Values[i][j] is the correlation of the columns i and j.
+	Values [][]Correlation
+	// Number of rows of the columns.
+	Count int
+}
+
+// Correlations - Returns the correlation matrix of the named columns, which must have the same length.
+func Correlations(names []string, columns [][]float64) (*CorrelationMatrix, error) {
+	if len(columns) < 2 {
+		return nil, fmt.Errorf("correlation needs at least 2 columns, got %d", len(columns))
+	}
+	m := &CorrelationMatrix{Names: names, Values: make([][]Correlation, len(columns)), Count: len(columns[0])}
+	for i := range columns {
+		if len(columns[i]) != m.Count {
+			return nil, fmt.Errorf("column '%s' has %d values, expected %d", names[i], len(columns[i]), m.Count)
+		}
+		m.Values[i] = make([]Correlation, len(columns))
+	}
+	for i := range columns {
+		for j := i; j < len(columns); j++ {
+			c := Correlate(columns[i], columns[j])
+			m.Values[i][j], m.Values[j][i] = c, c
+		}
+	}
+	return m, nil
+}
+
+// Matrix - Returns the matrix of the coefficients of the given method.
+func (m *CorrelationMatrix) Matrix(method CorrelationMethod) [][]float64 {
+	return m.matrix(func(c Correlation) float64 { return c.Coefficient(method) })
+}
+
+// CovarianceMatrix - Returns the matrix of the covariances, with the variances in the diagonal.
+func (m *CorrelationMatrix) CovarianceMatrix() [][]float64 {
+	return m.matrix(func(c Correlation) float64 { return c.Covariance })
+}
+
+func (m *CorrelationMatrix) matrix(f func(Correlation) float64) [][]float64 {
+	values := make([][]float64, len(m.Values))
+	for i, row := range m.Values {
+		values[i] = make([]float64, len(row))
+		for j, c := range row {
+			values[i][j] = f(c)
+		}
+	}
+	return values
+}
+
+// Pair - Correlation of two different columns.
+type Pair struct {
+	A, B string
+	Correlation
+	// Strength of the coefficient the pairs are ranked by, see Strength.
+	Strength string
+}
+
+// Pairs - Returns each pair of different columns ranked by the absolute value of the coefficient of the given method, strongest first, NaN values go last.
+// Pairs with the same strength keep the order of the columns.
+func (m *CorrelationMatrix) Pairs(method CorrelationMethod) []Pair {
+	var pairs []Pair
+	for i := range m.Names {
+		for j := i + 1; j < len(m.Names); j++ {
+			c := m.Values[i][j]
+			pairs = append(pairs, Pair{A: m.Names[i], B: m.Names[j], Correlation: c, Strength: Strength(c.Coefficient(method))})
+		}
+	}
+	sort.SliceStable(pairs, func(i, j int) bool {
+		a := math.Abs(pairs[i].Coefficient(method))
+		b := math.Abs(pairs[j].Coefficient(method))
+		if math.IsNaN(a) || math.IsNaN(b) {
+			return !math.IsNaN(a) && math.IsNaN(b)
+		}
+		return a > b
+	})
+	return pairs
+}
+
+// Strength - Returns the strength of a correlation coefficient by its absolute value:
+// "strong" from 0.7, "moderate" from 0.4, "weak" from 0.2 and otherwise "none", or "-" for NaN.
+func Strength(r float64) string {
+	r = math.Abs(r)
+	switch {
+	case math.IsNaN(r):
+		return "-"
+	case r >= 0.7:
+		return "strong"
+	case r >= 0.4:
+		return "moderate"
+	case r >= 0.2:
+		return "weak"
+	}
+	return "none"
+}
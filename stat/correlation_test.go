@@ -0,0 +1,115 @@
+// This file is part of csv-analysis.
+//
+// Copyright (C) 2017  David Gamba Rios
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+package stat
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestCorrelate(t *testing.T) {
+	tests := []struct {
+		x, y     []float64
+		expected Correlation
+	}{
+		// 2 discordant pairs out of 10, the squared rank differences add up to 4.
+		{[]float64{1, 2, 3, 4, 5}, []float64{1, 3, 2, 5, 4}, Correlation{Pearson: 0.8, Spearman: 0.8, Kendall: 0.6, Covariance: 2}},
+		// Monotonic but not linear.
+		{[]float64{1, 2, 3, 4}, []float64{1, 10, 100, 1000}, Correlation{Pearson: 0.824141, Spearman: 1, Kendall: 1, Covariance: 514.5}},
+		{[]float64{1, 2, 3}, []float64{3, 2, 1}, Correlation{Pearson: -1, Spearman: -1, Kendall: -1, Covariance: -1}},
+		// 4 concordant pairs, a pair tied in x and a pair tied in y.
+		{[]float64{1, 2, 2, 3}, []float64{1, 2, 3, 3}, Correlation{Pearson: 0.852803, Spearman: 0.833333, Kendall: 0.8, Covariance: 2.0 / 3}},
+		{[]float64{1, 2, 3}, []float64{1, 1, 1}, Correlation{Pearson: math.NaN(), Spearman: math.NaN(), Kendall: math.NaN(), Covariance: 0}},
+	}
+	for _, test := range tests {
+		c := Correlate(test.x, test.y)
+		for _, pair := range [][2]float64{
+			{c.Pearson, test.expected.Pearson},
+			{c.Spearman, test.expected.Spearman},
+			{c.Kendall, test.expected.Kendall},
+			{c.Covariance, test.expected.Covariance},
+		} {
+			if !(math.Abs(pair[0]-pair[1]) < 1e-4 || math.IsNaN(pair[0]) && math.IsNaN(pair[1])) {
+				t.Errorf("Wrong correlation of %v and %v: %+v != %+v\n", test.x, test.y, c, test.expected)
+				break
+			}
+		}
+	}
+}
+
+func TestKendallTau(t *testing.T) {
+	// Compare with the O(n²) definition.
+	x := []float64{3, 1, 4, 1, 5, 9, 2, 6, 5, 3, 5, 8, 9, 7, 9}
+	y := []float64{2, 7, 1, 8, 2, 8, 1, 8, 2, 8, 4, 5, 9, 0, 4}
+	var concordant, discordant, xTies, yTies float64
+	for i := range x {
+		for j := i + 1; j < len(x); j++ {
+			s := (x[i] - x[j]) * (y[i] - y[j])
+			switch {
+			case s > 0:
+				concordant++
+			case s < 0:
+				discordant++
+			}
+			if x[i] == x[j] {
+				xTies++
+			}
+			if y[i] == y[j] {
+				yTies++
+			}
+		}
+	}
+	pairs := float64(len(x) * (len(x) - 1) / 2)
+	expected := (concordant - discordant) / math.Sqrt((pairs-xTies)*(pairs-yTies))
+	tau := KendallTau(x, y)
+	if math.Abs(tau-expected) > 1e-12 {
+		t.Errorf("Wrong tau: %v != %v\n", tau, expected)
+	}
+}
+
+func TestCorrelations(t *testing.T) {
+	m, err := Correlations([]string{"a", "b", "c"}, [][]float64{{1, 2, 3, 4}, {2, 4, 6, 8}, {4, 1, 3, 2}})
+	if err != nil {
+		t.Fatalf("Unexpected error: %s\n", err)
+	}
+	if m.Count != 4 || m.Values[0][1] != m.Values[1][0] || m.Values[0][0].Pearson != 1 {
+		t.Errorf("Wrong matrix: %+v\n", m)
+	}
+	var names [][2]string
+	var strengths []string
+	for _, p := range m.Pairs(Pearson) {
+		names = append(names, [2]string{p.A, p.B})
+		strengths = append(strengths, p.Strength)
+	}
+	// b is a multiple of a, so a-c and b-c have the same strength and keep their order.
+	if !reflect.DeepEqual(names, [][2]string{{"a", "b"}, {"a", "c"}, {"b", "c"}}) {
+		t.Errorf("Wrong pairs: %v\n", names)
+	}
+	if !reflect.DeepEqual(strengths, []string{"strong", "moderate", "moderate"}) {
+		t.Errorf("Wrong strengths: %v\n", strengths)
+	}
+	if cov := m.CovarianceMatrix(); cov[1][1] != 20.0/3 {
+		t.Errorf("Wrong variance: %v\n", cov[1][1])
+	}
+	_, err = Correlations([]string{"a"}, [][]float64{{1, 2}})
+	if err == nil {
+		t.Errorf("Expected error with a single column\n")
+	}
+}
+
+func TestParseCorrelationMethod(t *testing.T) {
+	m, err := ParseCorrelationMethod("kendall")
+	if err != nil || m != Kendall || m.String() != "kendall" {
+		t.Errorf("Wrong method: %v, %v\n", m, err)
+	}
+	_, err = ParseCorrelationMethod("tau")
+	if err == nil || err.Error() != "unknown correlation method 'tau'" {
+		t.Errorf("Wrong error: %v\n", err)
+	}
+}
@@ -0,0 +1,151 @@
+// This file is part of csv-analysis.
+//
+// Copyright (C) 2017  David Gamba Rios
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package stat
+
+import (
+	"fmt"
+	"math"
+)
+
+// BinRule - How the number of bins of a histogram is chosen.
+type BinRule int
+
+const (
+	// Sturges - ceil(log2(n)) + 1 bins, for roughly normal data, too few bins for large datasets.
+	Sturges BinRule = iota
+	// Scott - Bins of width 3.49 * sd * n^(-1/3), optimal for normal data.
+	Scott
+	// FreedmanDiaconis - Bins of width 2 * IQR * n^(-1/3), robust to outliers and skewed data.
+	FreedmanDiaconis
+)
+
+var binRuleNames = map[BinRule]string{
+	Sturges:          "sturges",
+	Scott:            "scott",
+	FreedmanDiaconis: "fd",
+}
+
+func (r BinRule) String() string {
+	return binRuleNames[r]
+}
+
+// ParseBinRule - Returns the BinRule for the given name: sturges, scott or fd.
+func ParseBinRule(name string) (BinRule, error) {
+	for r, n := range binRuleNames {
+		if n == name {
+			return r, nil
+		}
+	}
+	return Sturges, fmt.Errorf("unknown bin rule '%s'", name)
+}
+
+// MaxBins - Maximum number of bins returned by Bins.
+const MaxBins = 1000
+
+// sturges - Returns the number of bins by Sturges' rule for n values.
+func sturges(n int) int {
+	return int(math.Ceil(math.Log2(float64(n)))) + 1
+}
+
+// Bins - Returns the number of equal width bins of a histogram of the data by the rule, at least 1.
+// Scott and FreedmanDiaconis fall back to Sturges when the data has no spread, like a zero IQR.
+// A tiny spread with a wide range, like a few extreme outliers, results in very narrow bins, so the number of bins is capped to the number of values and to MaxBins.
+func Bins(data []float64, rule BinRule) int {
+	n := len(data)
+	if n < 2 {
+		return 1
+	}
+	sorted := sortedCopy(data)
+	span := sorted[n-1] - sorted[0]
+	if span == 0 {
+		return 1
+	}
+	var width float64
+	switch rule {
+	case Scott:
+		_, sd := meanSD(data, 1)
+		width = 3.49 * sd * math.Pow(float64(n), -1.0/3)
+	case FreedmanDiaconis:
+		q1, _ := Quantile(sorted, 0.25, Linear)
+		q3, _ := Quantile(sorted, 0.75, Linear)
+		width = 2 * (q3 - q1) * math.Pow(float64(n), -1.0/3)
+	}
+	if width == 0 {
+		return sturges(n)
+	}
+	return int(math.Min(math.Ceil(span/width), math.Min(float64(n), MaxBins)))
+}
+
+// Bin - Histogram bin, from Min to Max, with the number of values in it.
+type Bin struct {
+	Min, Max float64
+	Count    int
+}
+
+// Histogram - Returns the histogram of the data with the given number of equal width bins between its minimum and maximum.
+// Bins include their minimum but not their maximum, except the last one.
+func Histogram(data []float64, bins int) []Bin {
+	if len(data) == 0 || bins < 1 {
+		return nil
+	}
+	sorted := sortedCopy(data)
+	min, max := sorted[0], sorted[len(sorted)-1]
+	width := (max - min) / float64(bins)
+	h := make([]Bin, bins)
+	for i := range h {
+		h[i].Min, h[i].Max = min+float64(i)*width, min+float64(i+1)*width
+	}
+	h[bins-1].Max = max
+	for _, x := range sorted {
+		i := bins - 1
+		if width > 0 {
+			i = int((x - min) / width)
+		}
+		if i >= bins {
+			i = bins - 1
+		}
+		h[i].Count++
+	}
+	return h
+}
+
+// SilvermanBandwidth - Returns the bandwidth of a gaussian kernel density estimate by Silverman's rule of thumb, 0.9 * min(sd, IQR / 1.34) * n^(-1/5).
+// When either spread is zero the other one is used. Returns NaN with less than 2 values or when all the values are equal.
+func SilvermanBandwidth(data []float64) float64 {
+	n := len(data)
+	if n < 2 {
+		return math.NaN()
+	}
+	sorted := sortedCopy(data)
+	_, sd := meanSD(data, 1)
+	q1, _ := Quantile(sorted, 0.25, Linear)
+	q3, _ := Quantile(sorted, 0.75, Linear)
+	spread := math.Min(sd, (q3-q1)/1.34)
+	if spread == 0 {
+		spread = math.Max(sd, (q3-q1)/1.34)
+	}
+	if spread == 0 {
+		return math.NaN()
+	}
+	return 0.9 * spread * math.Pow(float64(n), -0.2)
+}
+
+// KDE - Returns the gaussian kernel density estimate of the data with the given bandwidth, a probability density function.
+func KDE(data []float64, bandwidth float64) func(float64) float64 {
+	values := append([]float64{}, data...)
+	c := 1 / (float64(len(values)) * bandwidth * math.Sqrt(2*math.Pi))
+	return func(x float64) float64 {
+		var sum float64
+		for _, v := range values {
+			u := (x - v) / bandwidth
+			sum += math.Exp(-u * u / 2)
+		}
+		return c * sum
+	}
+}
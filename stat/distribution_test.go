@@ -0,0 +1,95 @@
+// This file is part of csv-analysis.
+//
+// Copyright (C) 2017  David Gamba Rios
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+package stat
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+var skewed = []float64{1, 2, 2, 3, 3, 3, 4, 4, 5, 10}
+
+func TestBins(t *testing.T) {
+	tests := []struct {
+		data     []float64
+		rule     BinRule
+		expected int
+	}{
+		{skewed, Sturges, 5},
+		// sd = 2.4967, width = 3.49 * 2.4967 * 10^(-1/3) = 4.04
+		{skewed, Scott, 3},
+		// IQR = 1.75, width = 2 * 1.75 * 10^(-1/3) = 1.62
+		{skewed, FreedmanDiaconis, 6},
+		// No IQR, fall back to Sturges.
+		{[]float64{1, 1, 1, 1, 1, 1, 1, 9}, FreedmanDiaconis, 4},
+		{[]float64{2, 2}, Scott, 1},
+		{[]float64{2}, Sturges, 1},
+		// A tiny IQR with a wide range, capped to the number of values and to MaxBins.
+		{narrow(100), FreedmanDiaconis, 100},
+		{narrow(5000), FreedmanDiaconis, MaxBins},
+	}
+	for _, test := range tests {
+		bins := Bins(test.data, test.rule)
+		if bins != test.expected {
+			t.Errorf("Wrong %s bins for %v: %d != %d\n", test.rule, test.data, bins, test.expected)
+		}
+	}
+}
+
+// narrow - Returns n values with an IQR of 1e-6 and an outlier at 1e6.
+func narrow(n int) []float64 {
+	data := make([]float64, n)
+	for i := range data {
+		data[i] = 1 + float64(i%2)*1e-6
+	}
+	data[n-1] = 1e6
+	return data
+}
+
+func TestHistogram(t *testing.T) {
+	h := Histogram(skewed, 3)
+	expected := []Bin{{1, 4, 6}, {4, 7, 3}, {7, 10, 1}}
+	if !reflect.DeepEqual(h, expected) {
+		t.Errorf("Wrong histogram: %v != %v\n", h, expected)
+	}
+	h = Histogram([]float64{5, 5}, 2)
+	if h[0].Count != 0 || h[1].Count != 2 {
+		t.Errorf("Wrong histogram: %v\n", h)
+	}
+}
+
+func TestKDE(t *testing.T) {
+	bw := SilvermanBandwidth(skewed)
+	if math.Abs(bw-0.741610) > 1e-6 {
+		t.Errorf("Wrong bandwidth: %v\n", bw)
+	}
+	f := KDE(skewed, bw)
+	// The density integrates to 1.
+	var area float64
+	for x := -10.0; x < 20; x += 0.01 {
+		area += f(x) * 0.01
+	}
+	if math.Abs(area-1) > 1e-6 {
+		t.Errorf("Wrong area: %v\n", area)
+	}
+	if !math.IsNaN(SilvermanBandwidth([]float64{3, 3, 3})) {
+		t.Errorf("Expected NaN bandwidth without spread\n")
+	}
+}
+
+func TestParseBinRule(t *testing.T) {
+	r, err := ParseBinRule("fd")
+	if err != nil || r != FreedmanDiaconis {
+		t.Errorf("Wrong rule: %v, %v\n", r, err)
+	}
+	_, err = ParseBinRule("auto")
+	if err == nil || err.Error() != "unknown bin rule 'auto'" {
+		t.Errorf("Wrong error: %v\n", err)
+	}
+}
@@ -0,0 +1,331 @@
+// This file is part of csv-analysis.
+//
+// Copyright (C) 2017  David Gamba Rios
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package stat
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/DavidGamba/csv-analysis/output"
+)
+
+// Formatter - Writes statistics in a given format.
+type Formatter interface {
+	// WriteSummary - Writes the statistics of a single dataset.
+	WriteSummary(s *Summary) error
+	// WriteTable - Writes the statistics of several datasets, one row per dataset, after the key columns with the given names.
+	WriteTable(keys []string, rows []Row) error
+	// WriteComparison - Writes the differences and hypothesis tests of datasets against a baseline.
+	WriteComparison(c *Comparison) error
+	// WriteCorrelation - Writes the correlation and covariance matrices and the pairs of columns ranked by the coefficient of the method.
+	WriteCorrelation(m *CorrelationMatrix, method CorrelationMethod, pairs []Pair) error
+}
+
+// tableStats - Statistics shown in a table, in order, followed by the percentiles and the IQR.
+var tableStats = []string{"count", "min", "max", "mean", "sd", "median", "mad", "sum"}
+
+// tableStatsHeader - Column names of the statistics in a table, the percentiles use their name.
+var tableStatsHeader = map[string]string{
+	"count": "Count", "min": "Min", "max": "Max", "mean": "Mean", "sd": "σ", "median": "Median", "mad": "MAD", "sum": "Sum", "iqr": "IQR",
+}
+
+// TableStats - Returns the names of the statistics shown in a table for the rows, in order.
+func TableStats(rows []Row) []string {
+	names := append([]string{}, tableStats...)
+	if len(rows) > 0 {
+		for _, p := range rows[0].Summary.Percentiles {
+			names = append(names, p.Name())
+		}
+	}
+	return append(names, "iqr")
+}
+
+// Text - Writes the statistics to W in a human readable format.
+// Tables are aligned with spaces and statistics that can't be calculated, like the mean of an empty dataset, are shown as "-".
+type Text struct {
+	W io.Writer
+	// Statistics added to tables after the TableStats, when not already in them, like the one the rows are sorted by, see Summary.Names.
+	Extra []string
+}
+
+// WriteSummary - Writes one statistic per line.
+func (t Text) WriteSummary(s *Summary) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Count: %d\n", s.Count)
+	fmt.Fprintf(&b, "Max: %f\n", s.Max)
+	fmt.Fprintf(&b, "Min: %f\n", s.Min)
+	fmt.Fprintf(&b, "Mean: %f\n", s.Mean)
+	fmt.Fprintf(&b, "Standard Deviation σ: %f%s\n", s.SD, relative(s.SD, s.Mean))
+	fmt.Fprintf(&b, "Variance σ²: %f\n", s.Variance)
+	fmt.Fprintf(&b, "Median: %f\n", s.Median)
+	fmt.Fprintf(&b, "Median Absolute Deviation MAD: %f%s\n", s.MAD, relative(s.MAD, s.Median))
+	fmt.Fprintf(&b, "Sum: %f\n", s.Sum)
+	fmt.Fprintf(&b, "Quartiles Q1, Q3: %f, %f\n", s.Q1, s.Q3)
+	fmt.Fprintf(&b, "Interquartile Range IQR: %f\n", s.IQR)
+	for _, p := range s.Percentiles {
+		fmt.Fprintf(&b, "Percentile %s: %f\n", p.Name(), p.Value)
+	}
+	cv := "-"
+	if !math.IsNaN(s.CV) {
+		cv = fmt.Sprintf("%f%%", s.CV*100)
+	}
+	fmt.Fprintf(&b, "Coefficient of Variation CV: %s\n", cv)
+	fmt.Fprintf(&b, "Skewness: %s\n", textFloat(s.Skewness))
+	fmt.Fprintf(&b, "Excess Kurtosis: %s\n", textFloat(s.Kurtosis))
+	fmt.Fprintf(&b, "Mode: %s\n", textFloat(s.Mode))
+	fmt.Fprintf(&b, "Geometric Mean: %s\n", textFloat(s.GeometricMean))
+	fmt.Fprintf(&b, "Harmonic Mean: %s\n", textFloat(s.HarmonicMean))
+	fmt.Fprintf(&b, "Trimmed Mean %g%%: %s\n", s.Trim*100, textFloat(s.TrimmedMean))
+	fmt.Fprintf(&b, "Winsorized Mean %g%%: %s\n", s.Trim*100, textFloat(s.WinsorizedMean))
+	for _, ci := range s.Intervals {
+		fmt.Fprintf(&b, "Confidence Interval %g%% %s (%s): %s, %s\n", s.Level*100, ci.Name, ci.Method, textFloat(ci.Lo), textFloat(ci.Hi))
+	}
+	_, err := io.WriteString(t.W, b.String())
+	return err
+}
+
+// relative - Returns x relative to the reference as ", x%", empty when the reference is zero.
+func relative(x, reference float64) string {
+	if reference == 0 || math.IsNaN(reference) {
+		return ""
+	}
+	return fmt.Sprintf(", %f%%", x*100/math.Abs(reference))
+}
+
+// textFloat - Returns the value as text, "-" when it can't be calculated.
+func textFloat(x float64) string {
+	if math.IsNaN(x) {
+		return "-"
+	}
+	return fmt.Sprintf("%f", x)
+}
+
+// WriteTable - Writes the rows as a table aligned with spaces.
+func (t Text) WriteTable(keys []string, rows []Row) error {
+	tw := tabwriter.NewWriter(t.W, 0, 8, 2, ' ', 0)
+	names := TableStats(rows)
+	shown := make(map[string]bool)
+	for _, name := range names {
+		shown[name] = true
+	}
+	for _, name := range t.Extra {
+		if !shown[name] {
+			names = append(names, name)
+			shown[name] = true
+		}
+	}
+	header := append([]string{}, keys...)
+	for _, name := range names {
+		if h, ok := tableStatsHeader[name]; ok {
+			name = h
+		}
+		header = append(header, name)
+	}
+	fmt.Fprintf(tw, "%s\n", strings.Join(header, "\t"))
+	for _, row := range rows {
+		fields := append([]string{}, row.Keys...)
+		for _, name := range names {
+			x, _ := row.Summary.Stat(name)
+			switch {
+			case math.IsNaN(x):
+				fields = append(fields, "-")
+			case name == "count":
+				fields = append(fields, fmt.Sprintf("%d", int(x)))
+			default:
+				fields = append(fields, fmt.Sprintf("%f", x))
+			}
+		}
+		fmt.Fprintf(tw, "%s\n", strings.Join(fields, "\t"))
+	}
+	return tw.Flush()
+}
+
+// WriteComparison - Writes the differences and the tests as two tables aligned with spaces.
+func (t Text) WriteComparison(c *Comparison) error {
+	tw := tabwriter.NewWriter(t.W, 0, 8, 2, ' ', 0)
+	fmt.Fprintf(tw, "Compared to %s:\n", c.Baseline)
+	fmt.Fprintf(tw, "Dataset\tStatistic\tBaseline\tValue\tDifference\tRelative\n")
+	for _, d := range c.Differences {
+		r := "-"
+		if !math.IsNaN(d.Relative) {
+			r = fmt.Sprintf("%f%%", d.Relative*100)
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n", d.Dataset, d.Stat, textFloat(d.Baseline), textFloat(d.Value), textFloat(d.Delta), r)
+	}
+	err := tw.Flush()
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(t.W, "\n")
+	tw = tabwriter.NewWriter(t.W, 0, 8, 2, ' ', 0)
+	fmt.Fprintf(tw, "Dataset\tTest\tStatistic\tDF\tp-value\tEffect\tEffect Size\n")
+	for _, r := range c.Tests {
+		df := "-"
+		switch {
+		case !math.IsNaN(r.DF2):
+			df = textDF(r.DF) + ", " + textDF(r.DF2)
+		case !math.IsNaN(r.DF):
+			df = textDF(r.DF)
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", r.Dataset, r.Test, textFloat(r.Statistic), df, textFloat(r.P), r.Effect, textFloat(r.EffectSize))
+	}
+	return tw.Flush()
+}
+
+// correlationMatrices - Titles of the matrices of a correlation, in order, see matrices.
+var correlationMatrices = []string{"Pearson", "Spearman", "Kendall", "Covariance"}
+
+// matrices - Returns the correlation matrices of the methods and the covariance matrix, in order.
+func matrices(m *CorrelationMatrix) [][][]float64 {
+	return [][][]float64{m.Matrix(Pearson), m.Matrix(Spearman), m.Matrix(Kendall), m.CovarianceMatrix()}
+}
+
+// WriteCorrelation - Writes each matrix as a table aligned with spaces, followed by the ranked pairs.
+func (t Text) WriteCorrelation(m *CorrelationMatrix, method CorrelationMethod, pairs []Pair) error {
+	fmt.Fprintf(t.W, "Correlation: %d columns, %d rows\n", len(m.Names), m.Count)
+	for i, values := range matrices(m) {
+		fmt.Fprintf(t.W, "\n%s:\n", correlationMatrices[i])
+		tw := tabwriter.NewWriter(t.W, 0, 8, 2, ' ', 0)
+		fmt.Fprintf(tw, "\t%s\n", strings.Join(m.Names, "\t"))
+		for j, row := range values {
+			fields := []string{m.Names[j]}
+			for _, x := range row {
+				fields = append(fields, textFloat(x))
+			}
+			fmt.Fprintf(tw, "%s\n", strings.Join(fields, "\t"))
+		}
+		err := tw.Flush()
+		if err != nil {
+			return err
+		}
+	}
+	fmt.Fprintf(t.W, "\nPairs by |%s|:\n", method)
+	tw := tabwriter.NewWriter(t.W, 0, 8, 2, ' ', 0)
+	fmt.Fprintf(tw, "A\tB\tPearson\tSpearman\tKendall\tCovariance\tStrength\n")
+	for _, p := range pairs {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", p.A, p.B, textFloat(p.Pearson), textFloat(p.Spearman), textFloat(p.Kendall), textFloat(p.Covariance), p.Strength)
+	}
+	return tw.Flush()
+}
+
+// textDF - Returns the degrees of freedom as text, with up to 2 decimals.
+func textDF(df float64) string {
+	return strconv.FormatFloat(math.Round(df*100)/100, 'f', -1, 64)
+}
+
+// Tables - Writes the statistics as tables to Out, for machine readable formats, see output.Table.
+//
+// Summaries are written as a "summary" table with a single row and tables of statistics as a "stats" table.
+// Their columns are the key columns, if any, the names of the statistics, see Summary.Names, "method", the quantile method, and, with confidence intervals, "confidence", their level.
+//
+// Comparisons are written as a "differences" table, with the columns baseline, dataset, statistic, baseline_value, value, difference and relative,
+// and a "tests" table, with the columns baseline, dataset, test, statistic, df, df2, p, effect and effect_size.
+//
+// Correlations are written as a "correlation" table, with the columns method, pearson, spearman, kendall or covariance, column and one per column name,
+// and a "pairs" table, with the columns a, b, pearson, spearman, kendall, covariance, strength and count.
+type Tables struct {
+	Out *output.Writer
+}
+
+// WriteSummary - Writes the summary as a table with a single row.
+func (t Tables) WriteSummary(s *Summary) error {
+	return t.Out.Write(statsTable("summary", nil, []Row{{Summary: s}}))
+}
+
+// WriteTable - Writes the rows as a table.
+func (t Tables) WriteTable(keys []string, rows []Row) error {
+	return t.Out.Write(statsTable("stats", keys, rows))
+}
+
+// WriteComparison - Writes the differences and the tests as two tables.
+func (t Tables) WriteComparison(c *Comparison) error {
+	differences := output.Table{
+		Type:    "differences",
+		Columns: []string{"baseline", "dataset", "statistic", "baseline_value", "value", "difference", "relative"},
+	}
+	for _, d := range c.Differences {
+		differences.Rows = append(differences.Rows, []interface{}{c.Baseline, d.Dataset, d.Stat, d.Baseline, d.Value, d.Delta, d.Relative})
+	}
+	err := t.Out.Write(differences)
+	if err != nil {
+		return err
+	}
+	tests := output.Table{
+		Type:    "tests",
+		Columns: []string{"baseline", "dataset", "test", "statistic", "df", "df2", "p", "effect", "effect_size"},
+	}
+	for _, r := range c.Tests {
+		tests.Rows = append(tests.Rows, []interface{}{c.Baseline, r.Dataset, r.Test, r.Statistic, r.DF, r.DF2, r.P, r.Effect, r.EffectSize})
+	}
+	return t.Out.Write(tests)
+}
+
+// WriteCorrelation - Writes the matrices as a table, one row per method and column, and the pairs as a table.
+func (t Tables) WriteCorrelation(m *CorrelationMatrix, method CorrelationMethod, pairs []Pair) error {
+	correlation := output.Table{Type: "correlation", Columns: append([]string{"method", "column"}, m.Names...)}
+	for i, values := range matrices(m) {
+		for j, row := range values {
+			r := []interface{}{strings.ToLower(correlationMatrices[i]), m.Names[j]}
+			for _, x := range row {
+				r = append(r, x)
+			}
+			correlation.Rows = append(correlation.Rows, r)
+		}
+	}
+	err := t.Out.Write(correlation)
+	if err != nil {
+		return err
+	}
+	table := output.Table{
+		Type:    "pairs",
+		Columns: []string{"a", "b", "pearson", "spearman", "kendall", "covariance", "strength", "count"},
+	}
+	for _, p := range pairs {
+		table.Rows = append(table.Rows, []interface{}{p.A, p.B, p.Pearson, p.Spearman, p.Kendall, p.Covariance, p.Strength, m.Count})
+	}
+	return t.Out.Write(table)
+}
+
+// statsTable - Returns the rows as an output table of the given type.
+func statsTable(name string, keys []string, rows []Row) output.Table {
+	t := output.Table{Type: name, Columns: append([]string{}, keys...)}
+	var names []string
+	if len(rows) > 0 {
+		names = rows[0].Summary.Names()
+	}
+	t.Columns = append(append(t.Columns, names...), "method")
+	level := len(rows) > 0 && rows[0].Summary.Level > 0
+	if level {
+		t.Columns = append(t.Columns, "confidence")
+	}
+	for _, row := range rows {
+		values := make([]interface{}, 0, len(t.Columns))
+		for _, key := range row.Keys {
+			values = append(values, key)
+		}
+		for _, name := range names {
+			x, _ := row.Summary.Stat(name)
+			if name == "count" {
+				values = append(values, row.Summary.Count)
+				continue
+			}
+			values = append(values, x)
+		}
+		values = append(values, row.Summary.Method.String())
+		if level {
+			values = append(values, row.Summary.Level)
+		}
+		t.Rows = append(t.Rows, values)
+	}
+	return t
+}
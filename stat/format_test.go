@@ -0,0 +1,100 @@
+// This file is part of csv-analysis.
+//
+// Copyright (C) 2017  David Gamba Rios
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+package stat
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/DavidGamba/csv-analysis/output"
+)
+
+func TestTextSummary(t *testing.T) {
+	var buf bytes.Buffer
+	s, _ := Describe([]float64{1, 2, 3}, Options{Percentiles: []float64{50}})
+	err := Text{W: &buf}.WriteSummary(s)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s\n", err)
+	}
+	expected := `Count: 3
+Max: 3.000000
+Min: 1.000000
+Mean: 2.000000
+Standard Deviation σ: 0.816497, 40.824829%
+Variance σ²: 0.666667
+Median: 2.000000
+Median Absolute Deviation MAD: 1.000000, 50.000000%
+Sum: 6.000000
+Quartiles Q1, Q3: 1.500000, 2.500000
+Interquartile Range IQR: 1.000000
+Percentile p50: 2.000000
+Coefficient of Variation CV: 40.824829%
+Skewness: 0.000000
+Excess Kurtosis: -
+Mode: 1.333333
+Geometric Mean: 1.817121
+Harmonic Mean: 1.636364
+Trimmed Mean 0%: 2.000000
+Winsorized Mean 0%: 2.000000
+`
+	if buf.String() != expected {
+		t.Errorf("Wrong summary:\n%s\n!=\n%s\n", buf.String(), expected)
+	}
+}
+
+func TestTextTable(t *testing.T) {
+	var buf bytes.Buffer
+	o := Options{Percentiles: []float64{90}}
+	rows := []Row{NewRow([]string{"a.csv"}, []float64{1, 2, 3}, o), NewRow([]string{"b.csv"}, nil, o), NewRow([]string{"all"}, []float64{1, 2, 3}, o)}
+	err := Text{W: &buf}.WriteTable([]string{"Source"}, rows)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s\n", err)
+	}
+	expected := `Source  Count  Min       Max       Mean      σ         Median    MAD       Sum       p90       IQR
+a.csv   3      1.000000  3.000000  2.000000  0.816497  2.000000  1.000000  6.000000  2.800000  1.000000
+b.csv   0      -         -         -         -         -         -         -         -         -
+all     3      1.000000  3.000000  2.000000  0.816497  2.000000  1.000000  6.000000  2.800000  1.000000
+`
+	if buf.String() != expected {
+		t.Errorf("Wrong table:\n%s\n!=\n%s\n", buf.String(), expected)
+	}
+
+	// Extra statistics that are not in the table are added at the end.
+	buf.Reset()
+	err = Text{W: &buf, Extra: []string{"mean", "cv"}}.WriteTable([]string{"Source"}, rows[:1])
+	if err != nil {
+		t.Fatalf("Unexpected error: %s\n", err)
+	}
+	expected = `Source  Count  Min       Max       Mean      σ         Median    MAD       Sum       p90       IQR       cv
+a.csv   3      1.000000  3.000000  2.000000  0.816497  2.000000  1.000000  6.000000  2.800000  1.000000  0.408248
+`
+	if buf.String() != expected {
+		t.Errorf("Wrong table:\n%s\n!=\n%s\n", buf.String(), expected)
+	}
+}
+
+func TestTables(t *testing.T) {
+	var buf bytes.Buffer
+	o := Options{Percentiles: []float64{90}}
+	f := Tables{Out: output.NewWriter(&buf, output.JSON)}
+	s, _ := Describe([]float64{1, 2, 3}, o)
+	err := f.WriteSummary(s)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s\n", err)
+	}
+	err = f.WriteTable([]string{"host"}, []Row{NewRow([]string{"a"}, []float64{2}, o), NewRow([]string{"b"}, nil, o)})
+	if err != nil {
+		t.Fatalf("Unexpected error: %s\n", err)
+	}
+	expected := `{"type":"summary","rows":[{"count":3,"min":1,"max":3,"mean":2,"sd":0.816496580927726,"variance":0.6666666666666666,"median":2,"mad":1,"sum":6,"q1":1.5,"q3":2.5,"p90":2.8,"iqr":1,"cv":0.408248290463863,"skewness":0,"kurtosis":null,"mode":1.3333333333333333,"geometric_mean":1.8171205928321397,"harmonic_mean":1.6363636363636365,"trimmed_mean":2,"winsorized_mean":2,"method":"linear"}]}
+{"type":"stats","rows":[{"host":"a","count":1,"min":2,"max":2,"mean":2,"sd":0,"variance":0,"median":2,"mad":0,"sum":2,"q1":2,"q3":2,"p90":2,"iqr":0,"cv":0,"skewness":null,"kurtosis":null,"mode":2,"geometric_mean":2,"harmonic_mean":2,"trimmed_mean":2,"winsorized_mean":2,"method":"linear"},{"host":"b","count":0,"min":null,"max":null,"mean":null,"sd":null,"variance":null,"median":null,"mad":null,"sum":null,"q1":null,"q3":null,"p90":null,"iqr":null,"cv":null,"skewness":null,"kurtosis":null,"mode":null,"geometric_mean":null,"harmonic_mean":null,"trimmed_mean":null,"winsorized_mean":null,"method":"linear"}]}
+`
+	if buf.String() != expected {
+		t.Errorf("Wrong output:\n%s\n!=\n%s\n", buf.String(), expected)
+	}
+}
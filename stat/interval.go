@@ -0,0 +1,173 @@
+// This file is part of csv-analysis.
+//
+// Copyright (C) 2017  David Gamba Rios
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package stat
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"strings"
+
+	"gonum.org/v1/gonum/stat/distuv"
+)
+
+// Interval - Confidence interval of a statistic.
+type Interval struct {
+	// Name of the statistic, see Summary.Names.
+	Name string
+	// How the interval was calculated: "t", "order" or "bootstrap".
+	Method string
+	Lo, Hi float64
+}
+
+// boundNames - Returns the names of the bounds of the interval, like "mean_ci_lo" and "mean_ci_hi", or "mean_boot_lo" and "mean_boot_hi" for bootstrap intervals.
+func (ci Interval) boundNames() (string, string) {
+	if ci.Method == "bootstrap" {
+		return ci.Name + "_boot_lo", ci.Name + "_boot_hi"
+	}
+	return ci.Name + "_ci_lo", ci.Name + "_ci_hi"
+}
+
+// DefaultResamples - Number of bootstrap resamples by default.
+const DefaultResamples = 1000
+
+// MeanCI - Returns the t-based confidence interval of the mean of the data at the given level, between 0 and 1.
+// It assumes the mean is normally distributed, which holds for large samples of most distributions.
+// The interval is NaN with less than 2 values.
+func MeanCI(data []float64, level float64) Interval {
+	n := len(data)
+	ci := Interval{Name: "mean", Method: "t", Lo: math.NaN(), Hi: math.NaN()}
+	if n < 2 {
+		return ci
+	}
+	m, sd := meanSD(data, 1)
+	t := distuv.StudentsT{Mu: 0, Sigma: 1, Nu: float64(n - 1)}.Quantile((1 + level) / 2)
+	e := t * sd / math.Sqrt(float64(n))
+	ci.Lo, ci.Hi = m-e, m+e
+	return ci
+}
+
+// QuantileCI - Returns the distribution free confidence interval of the p quantile, with p between 0 and 1, of the sorted data at the given level.
+// The bounds are order statistics, data points, chosen with the binomial distribution so the coverage is at least the level.
+// A bound is NaN when there is not enough data to reach the level, for example for extreme percentiles of small samples.
+func QuantileCI(sorted []float64, p, level float64) Interval {
+	n := len(sorted)
+	ci := Interval{Method: "order", Lo: math.NaN(), Hi: math.NaN()}
+	alpha := 1 - level
+	// The number of values below the quantile is Binomial(n, p).
+	// lo is the largest rank with P(B < lo) <= alpha/2 and hi the smallest rank with P(B < hi) >= 1 - alpha/2, both 1-based.
+	lo, hi := 0, n+1
+	cdf := 0.0 // P(B < k)
+	for k := 0; k <= n; k++ {
+		if cdf <= alpha/2 {
+			lo = k
+		}
+		if cdf >= 1-alpha/2 {
+			hi = k
+			break
+		}
+		cdf += binomialProb(n, k, p)
+	}
+	if lo >= 1 {
+		ci.Lo = sorted[lo-1]
+	}
+	if hi <= n {
+		ci.Hi = sorted[hi-1]
+	}
+	return ci
+}
+
+// binomialProb - Returns P(B = k) for B ~ Binomial(n, p).
+func binomialProb(n, k int, p float64) float64 {
+	switch {
+	case p == 0:
+		if k == 0 {
+			return 1
+		}
+		return 0
+	case p == 1:
+		if k == n {
+			return 1
+		}
+		return 0
+	}
+	a, _ := math.Lgamma(float64(n + 1))
+	b, _ := math.Lgamma(float64(k + 1))
+	c, _ := math.Lgamma(float64(n - k + 1))
+	return math.Exp(a - b - c + float64(k)*math.Log(p) + float64(n-k)*math.Log(1-p))
+}
+
+// Bootstrap - Returns the percentile bootstrap confidence interval of the statistic f of the data at the given level.
+// The data is resampled with replacement the given number of times, with a random source seeded with seed, so the interval is reproducible.
+func Bootstrap(data []float64, f func([]float64) (float64, error), level float64, resamples int, seed int64) (Interval, error) {
+	ci := Interval{Method: "bootstrap", Lo: math.NaN(), Hi: math.NaN()}
+	n := len(data)
+	if n == 0 || resamples < 1 {
+		return ci, nil
+	}
+	r := rand.New(rand.NewSource(seed))
+	sample := make([]float64, n)
+	var values []float64
+	for i := 0; i < resamples; i++ {
+		for j := range sample {
+			sample[j] = data[r.Intn(n)]
+		}
+		x, err := f(sample)
+		if err != nil {
+			return ci, err
+		}
+		if !math.IsNaN(x) {
+			values = append(values, x)
+		}
+	}
+	if len(values) == 0 {
+		return ci, nil
+	}
+	sort.Float64s(values)
+	ci.Lo, _ = Quantile(values, (1-level)/2, Linear)
+	ci.Hi, _ = Quantile(values, (1+level)/2, Linear)
+	return ci, nil
+}
+
+// intervals - Returns the confidence intervals of the summary of the data: the mean, the median, the percentiles and the bootstrapped statistics of the options.
+// The intervals are NaN when there is no data.
+func (s *Summary) intervals(data []float64, o Options) ([]Interval, error) {
+	sorted := sortedCopy(data)
+	intervals := []Interval{MeanCI(data, o.Level)}
+	median := QuantileCI(sorted, 0.5, o.Level)
+	median.Name = "median"
+	intervals = append(intervals, median)
+	for _, p := range o.Percentiles {
+		ci := QuantileCI(sorted, p/100, o.Level)
+		ci.Name = percentileName(p)
+		intervals = append(intervals, ci)
+	}
+	inner := o
+	inner.Level, inner.Bootstrap = 0, nil
+	for _, name := range o.Bootstrap {
+		if _, ok := s.Stat(name); !ok {
+			return nil, fmt.Errorf("unknown statistic '%s', use one of: %s", name, strings.Join(s.Names(), ", "))
+		}
+		ci, err := Bootstrap(data, func(sample []float64) (float64, error) {
+			r, err := Describe(sample, inner)
+			if err != nil {
+				return math.NaN(), err
+			}
+			x, _ := r.Stat(name)
+			return x, nil
+		}, o.Level, o.resamples(), o.Seed)
+		if err != nil {
+			return nil, err
+		}
+		ci.Name = name
+		intervals = append(intervals, ci)
+	}
+	return intervals, nil
+}
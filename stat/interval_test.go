@@ -0,0 +1,105 @@
+// This file is part of csv-analysis.
+//
+// Copyright (C) 2017  David Gamba Rios
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+package stat
+
+import (
+	"math"
+	"testing"
+)
+
+func TestMeanCI(t *testing.T) {
+	ci := MeanCI([]float64{1, 2, 3, 4, 5}, 0.95)
+	// t(0.975, 4) = 2.776445
+	e := 2.776445 * math.Sqrt(2.5) / math.Sqrt(5)
+	if math.Abs(ci.Lo-(3-e)) > 1e-5 || math.Abs(ci.Hi-(3+e)) > 1e-5 {
+		t.Errorf("Wrong interval: %+v\n", ci)
+	}
+	ci = MeanCI([]float64{1}, 0.95)
+	if !math.IsNaN(ci.Lo) || !math.IsNaN(ci.Hi) {
+		t.Errorf("Wrong interval: %+v\n", ci)
+	}
+}
+
+func TestQuantileCI(t *testing.T) {
+	sorted := []float64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}
+	tests := []struct {
+		p, level float64
+		lo, hi   float64
+	}{
+		// Order statistics 2 and 9, with a coverage of 97.85%.
+		{0.5, 0.95, 2, 9},
+		// Order statistics 3 and 8, with a coverage of 89.06%, the 90% interval needs 2 and 9.
+		{0.5, 0.85, 3, 8},
+		{0.5, 0.9, 2, 9},
+		{0.99, 0.95, 9, math.NaN()},
+		{0.01, 0.95, math.NaN(), 2},
+	}
+	for _, test := range tests {
+		ci := QuantileCI(sorted, test.p, test.level)
+		if !sameFloat(ci.Lo, test.lo) || !sameFloat(ci.Hi, test.hi) {
+			t.Errorf("Wrong interval for %g at %g: %+v != [%g, %g]\n", test.p, test.level, ci, test.lo, test.hi)
+		}
+	}
+}
+
+func TestBootstrap(t *testing.T) {
+	data := []float64{4, 8, 15, 16, 23, 42, 7, 11, 9, 10}
+	f := func(sample []float64) (float64, error) { return mean(sample), nil }
+	a, err := Bootstrap(data, f, 0.95, 500, 7)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s\n", err)
+	}
+	b, _ := Bootstrap(data, f, 0.95, 500, 7)
+	if a != b {
+		t.Errorf("Not reproducible: %+v != %+v\n", a, b)
+	}
+	if !(a.Lo < mean(data) && mean(data) < a.Hi) || a.Lo < 4 || a.Hi > 42 {
+		t.Errorf("Wrong interval: %+v\n", a)
+	}
+	c, _ := Bootstrap([]float64{3, 3, 3}, f, 0.95, 100, 1)
+	if c.Lo != 3 || c.Hi != 3 {
+		t.Errorf("Wrong interval: %+v\n", c)
+	}
+}
+
+func TestDescribeIntervals(t *testing.T) {
+	o := Options{Percentiles: []float64{90}, Level: 0.9, Bootstrap: []string{"sd"}, Resamples: 200, Seed: 1}
+	s, err := Describe([]float64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}, o)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s\n", err)
+	}
+	var names []string
+	for _, ci := range s.Intervals {
+		names = append(names, ci.Name+" "+ci.Method)
+	}
+	expected := []string{"mean t", "median order", "p90 order", "sd bootstrap"}
+	if len(names) != len(expected) {
+		t.Fatalf("Wrong intervals: %v != %v\n", names, expected)
+	}
+	for i := range names {
+		if names[i] != expected[i] {
+			t.Errorf("Wrong intervals: %v != %v\n", names, expected)
+		}
+	}
+	if x, ok := s.Stat("median_ci_lo"); !ok || x != 2 {
+		t.Errorf("Wrong median_ci_lo: %v\n", x)
+	}
+	if x, ok := s.Stat("sd_boot_hi"); !ok || math.IsNaN(x) {
+		t.Errorf("Wrong sd_boot_hi: %v\n", x)
+	}
+	o.Bootstrap = []string{"average"}
+	_, err = Describe([]float64{1, 2}, o)
+	if err == nil {
+		t.Errorf("Expected error for unknown statistic\n")
+	}
+}
+
+// sameFloat - Whether the values are equal or both NaN.
+func sameFloat(a, b float64) bool {
+	return a == b || math.IsNaN(a) && math.IsNaN(b)
+}
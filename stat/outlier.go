@@ -0,0 +1,249 @@
+// This file is part of csv-analysis.
+//
+// Copyright (C) 2017  David Gamba Rios
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package stat
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"gonum.org/v1/gonum/stat/distuv"
+)
+
+// OutlierMethod - How outliers are detected.
+type OutlierMethod int
+
+const (
+	// IQRFences - Values below Q1 - k * IQR or above Q3 + k * IQR, Tukey's fences.
+	IQRFences OutlierMethod = iota
+	// ZScore - Values more than the threshold standard deviations away from the mean.
+	ZScore
+	// ModifiedZScore - Values with a modified z-score, 0.6745 * (x - median) / MAD, above the threshold, Iglewicz and Hoaglin.
+	ModifiedZScore
+	// Grubbs - Grubbs' test, two-sided, repeated while it finds an outlier at the significance level.
+	// Assumes the data is normally distributed.
+	Grubbs
+	// GeneralizedESD - Rosner's generalized extreme studentized deviate test, for up to a maximum number of outliers at the significance level.
+	// Assumes the data is normally distributed.
+	GeneralizedESD
+)
+
+var outlierMethodNames = map[OutlierMethod]string{
+	IQRFences:      "iqr",
+	ZScore:         "zscore",
+	ModifiedZScore: "modified-zscore",
+	Grubbs:         "grubbs",
+	GeneralizedESD: "esd",
+}
+
+func (m OutlierMethod) String() string {
+	return outlierMethodNames[m]
+}
+
+// ParseOutlierMethod - Returns the OutlierMethod for the given name: iqr, zscore, modified-zscore, grubbs or esd.
+func ParseOutlierMethod(name string) (OutlierMethod, error) {
+	for m, n := range outlierMethodNames {
+		if n == name {
+			return m, nil
+		}
+	}
+	return IQRFences, fmt.Errorf("unknown outlier method '%s'", name)
+}
+
+// OutlierOptions - Settings of the outlier detection.
+type OutlierOptions struct {
+	Method OutlierMethod
+	// Threshold of the method: k for IQRFences, the score for ZScore and ModifiedZScore and the significance level, alpha, for Grubbs and GeneralizedESD.
+	// 0 uses DefaultThreshold.
+	Threshold float64
+	// Maximum number of outliers for GeneralizedESD, 0 means 10% of the data, at least 1.
+	Max int
+	// Quantile method of the quartiles for IQRFences.
+	Quantile QuantileMethod
+}
+
+// DefaultThreshold - Returns the usual threshold of the method: 1.5 for IQRFences, 3 for ZScore, 3.5 for ModifiedZScore and 0.05 for Grubbs and GeneralizedESD.
+func DefaultThreshold(m OutlierMethod) float64 {
+	switch m {
+	case ZScore:
+		return 3
+	case ModifiedZScore:
+		return 3.5
+	case Grubbs, GeneralizedESD:
+		return 0.05
+	}
+	return 1.5
+}
+
+// threshold - Returns the threshold, the default one when not set.
+func (o OutlierOptions) threshold() float64 {
+	if o.Threshold == 0 {
+		return DefaultThreshold(o.Method)
+	}
+	return o.Threshold
+}
+
+// String - Returns a description of the method with its threshold, like "modified-zscore > 3.5".
+func (o OutlierOptions) String() string {
+	t := o.threshold()
+	switch o.Method {
+	case IQRFences:
+		return fmt.Sprintf("iqr k=%g", t)
+	case Grubbs:
+		return fmt.Sprintf("grubbs alpha=%g", t)
+	case GeneralizedESD:
+		return fmt.Sprintf("esd alpha=%g max=%d", t, o.Max)
+	}
+	return fmt.Sprintf("%s > %g", o.Method, t)
+}
+
+// Outliers - Returns the 0-based indexes of the outliers of the data, in ascending order.
+func Outliers(data []float64, o OutlierOptions) ([]int, error) {
+	if len(data) == 0 {
+		return nil, nil
+	}
+	t := o.threshold()
+	if t < 0 || (o.Method == Grubbs || o.Method == GeneralizedESD) && t >= 1 {
+		return nil, fmt.Errorf("invalid %s threshold %g", o.Method, t)
+	}
+	var flagged []int
+	switch o.Method {
+	case IQRFences:
+		q, err := Percentiles(data, []float64{25, 75}, o.Quantile)
+		if err != nil {
+			return nil, err
+		}
+		lo, hi := q[0]-t*(q[1]-q[0]), q[1]+t*(q[1]-q[0])
+		for i, x := range data {
+			if x < lo || x > hi {
+				flagged = append(flagged, i)
+			}
+		}
+	case ZScore:
+		m, sd := meanSD(data, 0)
+		if sd == 0 {
+			return nil, nil
+		}
+		for i, x := range data {
+			if math.Abs(x-m)/sd > t {
+				flagged = append(flagged, i)
+			}
+		}
+	case ModifiedZScore:
+		sorted := sortedCopy(data)
+		median, _ := Quantile(sorted, 0.5, Linear)
+		deviations := make([]float64, len(data))
+		for i, x := range data {
+			deviations[i] = math.Abs(x - median)
+		}
+		mad, _ := Quantile(sortedCopy(deviations), 0.5, Linear)
+		if mad == 0 {
+			return nil, nil
+		}
+		for i, d := range deviations {
+			if 0.6745*d/mad > t {
+				flagged = append(flagged, i)
+			}
+		}
+	case Grubbs:
+		remaining := indexes(len(data))
+		for len(remaining) >= 3 {
+			i, g := mostExtreme(data, remaining)
+			if g <= grubbsCritical(len(remaining), t) {
+				break
+			}
+			flagged = append(flagged, remaining[i])
+			remaining = append(remaining[:i], remaining[i+1:]...)
+		}
+	case GeneralizedESD:
+		max := o.Max
+		if max <= 0 {
+			max = len(data) / 10
+			if max < 1 {
+				max = 1
+			}
+		}
+		n := len(data)
+		remaining := indexes(n)
+		var removed []int
+		outliers := 0
+		for i := 1; i <= max && n-i-1 >= 1; i++ {
+			j, r := mostExtreme(data, remaining)
+			removed = append(removed, remaining[j])
+			remaining = append(remaining[:j], remaining[j+1:]...)
+			if r > esdCritical(n, i, t) {
+				outliers = i
+			}
+		}
+		flagged = removed[:outliers]
+	default:
+		return nil, fmt.Errorf("unknown outlier method '%s'", o.Method)
+	}
+	sort.Ints(flagged)
+	return flagged, nil
+}
+
+// mostExtreme - Returns the position in remaining of the value of the data furthest from the mean of the remaining values and its studentized deviation, |x - mean| / sd with the sample standard deviation.
+func mostExtreme(data []float64, remaining []int) (int, float64) {
+	values := make([]float64, len(remaining))
+	for i, j := range remaining {
+		values[i] = data[j]
+	}
+	m, sd := meanSD(values, 1)
+	best, deviation := 0, 0.0
+	for i, x := range values {
+		if d := math.Abs(x - m); d > deviation {
+			best, deviation = i, d
+		}
+	}
+	if sd == 0 {
+		return best, 0
+	}
+	return best, deviation / sd
+}
+
+// grubbsCritical - Returns the critical value of the two-sided Grubbs' test for n values at the significance level alpha.
+func grubbsCritical(n int, alpha float64) float64 {
+	f := float64(n)
+	tc := distuv.StudentsT{Mu: 0, Sigma: 1, Nu: f - 2}.Quantile(1 - alpha/(2*f))
+	return (f - 1) / math.Sqrt(f) * math.Sqrt(tc*tc/(f-2+tc*tc))
+}
+
+// esdCritical - Returns the critical value, lambda, of the i-th step of the generalized ESD test for n values at the significance level alpha.
+func esdCritical(n, i int, alpha float64) float64 {
+	f := float64(n - i + 1)
+	tc := distuv.StudentsT{Mu: 0, Sigma: 1, Nu: f - 2}.Quantile(1 - alpha/(2*f))
+	return (f - 1) * tc / math.Sqrt((f-2+tc*tc)*f)
+}
+
+// meanSD - Returns the mean and the standard deviation of the data, with ddof delta degrees of freedom, 0 for the population and 1 for the sample standard deviation.
+func meanSD(data []float64, ddof int) (float64, float64) {
+	m := mean(data)
+	var sum float64
+	for _, x := range data {
+		sum += (x - m) * (x - m)
+	}
+	return m, math.Sqrt(sum / float64(len(data)-ddof))
+}
+
+// sortedCopy - Returns a sorted copy of the data.
+func sortedCopy(data []float64) []float64 {
+	sorted := append([]float64{}, data...)
+	sort.Float64s(sorted)
+	return sorted
+}
+
+// indexes - Returns the indexes 0 to n-1.
+func indexes(n int) []int {
+	s := make([]int, n)
+	for i := range s {
+		s[i] = i
+	}
+	return s
+}
@@ -0,0 +1,76 @@
+// This file is part of csv-analysis.
+//
+// Copyright (C) 2017  David Gamba Rios
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+package stat
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestOutliers(t *testing.T) {
+	data := []float64{10, 11, 12, 11, 10, -20, 12, 11, 10, 11, 50, 12}
+	tests := []struct {
+		options  OutlierOptions
+		expected []int
+	}{
+		{OutlierOptions{Method: IQRFences}, []int{5, 10}},
+		{OutlierOptions{Method: IQRFences, Threshold: 30}, nil},
+		// With few values the z-score of an outlier is small, it can't be more than (n - 1) / sqrt(n).
+		{OutlierOptions{Method: ZScore}, nil},
+		{OutlierOptions{Method: ZScore, Threshold: 2}, []int{5, 10}},
+		{OutlierOptions{Method: ZScore, Threshold: 2.5}, []int{10}},
+		{OutlierOptions{Method: ModifiedZScore}, []int{5, 10}},
+		{OutlierOptions{Method: Grubbs}, []int{5, 10}},
+		{OutlierOptions{Method: GeneralizedESD}, []int{10}},
+		{OutlierOptions{Method: GeneralizedESD, Max: 3}, []int{5, 10}},
+	}
+	for _, test := range tests {
+		flagged, err := Outliers(data, test.options)
+		if err != nil {
+			t.Fatalf("Unexpected error: %s\n", err)
+		}
+		if !reflect.DeepEqual(flagged, test.expected) {
+			t.Errorf("Wrong outliers for %s: %v != %v\n", test.options, flagged, test.expected)
+		}
+	}
+	flagged, err := Outliers([]float64{1, 1, 1, 1}, OutlierOptions{Method: ModifiedZScore})
+	if err != nil || flagged != nil {
+		t.Errorf("Unexpected outliers: %v, %v\n", flagged, err)
+	}
+	_, err = Outliers(data, OutlierOptions{Method: Grubbs, Threshold: 5})
+	if err == nil || err.Error() != "invalid grubbs threshold 5" {
+		t.Errorf("Unexpected error: %v\n", err)
+	}
+}
+
+func TestGrubbsCritical(t *testing.T) {
+	// Two-sided critical values at alpha 0.05 from the Grubbs' test tables.
+	for n, expected := range map[int]float64{3: 1.1543, 8: 2.1266, 10: 2.2900, 20: 2.7082} {
+		g := grubbsCritical(n, 0.05)
+		if math.Abs(g-expected) > 1e-3 {
+			t.Errorf("Wrong critical value for %d: %f != %f\n", n, g, expected)
+		}
+	}
+}
+
+func TestParseOutlierMethod(t *testing.T) {
+	for _, name := range []string{"iqr", "zscore", "modified-zscore", "grubbs", "esd"} {
+		m, err := ParseOutlierMethod(name)
+		if err != nil {
+			t.Fatalf("Unexpected error: %s\n", err)
+		}
+		if m.String() != name {
+			t.Errorf("Wrong method: %s != %s\n", m, name)
+		}
+	}
+	_, err := ParseOutlierMethod("dixon")
+	if err == nil || err.Error() != "unknown outlier method 'dixon'" {
+		t.Errorf("Unexpected error: %v\n", err)
+	}
+}
@@ -0,0 +1,146 @@
+// This file is part of csv-analysis.
+//
+// Copyright (C) 2017  David Gamba Rios
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package stat
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// QuantileMethod - How a quantile that falls between two data points is calculated.
+// The names follow numpy.quantile, the equivalent Hyndman and Fan (R) types are given for each method.
+type QuantileMethod int
+
+const (
+	// Linear - Linear interpolation between the closest ranks, R type 7.
+	// Default of numpy, R and Excel PERCENTILE.INC.
+	Linear QuantileMethod = iota
+	// Lower - The lower of the two closest data points.
+	Lower
+	// Higher - The higher of the two closest data points.
+	Higher
+	// Nearest - The nearest of the two closest data points, ties go to the even rank.
+	Nearest
+	// Midpoint - The mean of the two closest data points.
+	Midpoint
+	// InvertedCDF - Nearest rank, the smallest data point with at least p of the data at or below it, R type 1.
+	InvertedCDF
+	// Hazen - Linear interpolation with p = (k - 0.5) / n, R type 5.
+	Hazen
+	// Weibull - Linear interpolation with p = k / (n + 1), R type 6, Excel PERCENTILE.EXC and Minitab.
+	Weibull
+	// MedianUnbiased - Linear interpolation with p = (k - 1/3) / (n + 1/3), R type 8, recommended by Hyndman and Fan.
+	MedianUnbiased
+)
+
+var quantileMethodNames = map[QuantileMethod]string{
+	Linear:         "linear",
+	Lower:          "lower",
+	Higher:         "higher",
+	Nearest:        "nearest",
+	Midpoint:       "midpoint",
+	InvertedCDF:    "inverted-cdf",
+	Hazen:          "hazen",
+	Weibull:        "weibull",
+	MedianUnbiased: "median-unbiased",
+}
+
+func (m QuantileMethod) String() string {
+	return quantileMethodNames[m]
+}
+
+// ParseQuantileMethod - Returns the QuantileMethod for the given name, for example linear or weibull.
+func ParseQuantileMethod(name string) (QuantileMethod, error) {
+	for m, n := range quantileMethodNames {
+		if n == name {
+			return m, nil
+		}
+	}
+	return Linear, fmt.Errorf("unknown quantile method '%s'", name)
+}
+
+// Quantile - Returns the p quantile, with p between 0 and 1, of the sorted data.
+func Quantile(sorted []float64, p float64, m QuantileMethod) (float64, error) {
+	n := len(sorted)
+	if n == 0 {
+		return math.NaN(), fmt.Errorf("quantile of empty data")
+	}
+	if p < 0 || p > 1 || math.IsNaN(p) {
+		return math.NaN(), fmt.Errorf("quantile %g out of range [0, 1]", p)
+	}
+	// h - 0-based fractional rank of the quantile.
+	var h float64
+	switch m {
+	case InvertedCDF:
+		h = math.Ceil(float64(n)*p) - 1
+	case Hazen:
+		h = float64(n)*p - 0.5
+	case Weibull:
+		h = float64(n+1)*p - 1
+	case MedianUnbiased:
+		h = (float64(n)+1.0/3)*p - 2.0/3
+	default:
+		h = float64(n-1) * p
+	}
+	h = math.Max(0, math.Min(float64(n-1), h))
+	lo := math.Floor(h)
+	hi := math.Ceil(h)
+	a, b := sorted[int(lo)], sorted[int(hi)]
+	switch m {
+	case Lower:
+		return a, nil
+	case Higher:
+		return b, nil
+	case Nearest:
+		return sorted[int(math.RoundToEven(h))], nil
+	case Midpoint:
+		return (a + b) / 2, nil
+	}
+	return a + (h-lo)*(b-a), nil
+}
+
+// Percentiles - Returns the percentiles, between 0 and 100, of the data.
+func Percentiles(data []float64, percentiles []float64, m QuantileMethod) ([]float64, error) {
+	sorted := append([]float64{}, data...)
+	sort.Float64s(sorted)
+	values := make([]float64, len(percentiles))
+	for i, p := range percentiles {
+		var err error
+		values[i], err = Quantile(sorted, p/100, m)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return values, nil
+}
+
+// ParsePercentiles - Parses a comma separated list of percentiles, like "50,90,99.9" or "p50,p90,p99.9".
+func ParsePercentiles(s string) ([]float64, error) {
+	var percentiles []float64
+	for _, field := range strings.Split(s, ",") {
+		field = strings.TrimPrefix(strings.ToLower(strings.TrimSpace(field)), "p")
+		if field == "" {
+			continue
+		}
+		p, err := strconv.ParseFloat(field, 64)
+		if err != nil || p < 0 || p > 100 {
+			return nil, fmt.Errorf("invalid percentile '%s', must be between 0 and 100", field)
+		}
+		percentiles = append(percentiles, p)
+	}
+	return percentiles, nil
+}
+
+// percentileName - Returns the name of the percentile, like "p99.9".
+func percentileName(p float64) string {
+	return "p" + strconv.FormatFloat(p, 'f', -1, 64)
+}
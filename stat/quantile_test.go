@@ -0,0 +1,83 @@
+// This file is part of csv-analysis.
+//
+// Copyright (C) 2017  David Gamba Rios
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+package stat
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestPercentiles(t *testing.T) {
+	data := []float64{50, 15, 40, 20, 35}
+	tests := []struct {
+		method   QuantileMethod
+		expected []float64
+	}{
+		{Linear, []float64{15, 29, 35, 46, 50}},
+		{Lower, []float64{15, 20, 35, 40, 50}},
+		{Higher, []float64{15, 35, 35, 50, 50}},
+		{Nearest, []float64{15, 35, 35, 50, 50}},
+		{Midpoint, []float64{15, 27.5, 35, 45, 50}},
+		{InvertedCDF, []float64{15, 20, 35, 50, 50}},
+		{Hazen, []float64{15, 27.5, 35, 50, 50}},
+		{Weibull, []float64{15, 26, 35, 50, 50}},
+		{MedianUnbiased, []float64{15, 27, 35, 50, 50}},
+	}
+	for _, test := range tests {
+		values, err := Percentiles(data, []float64{0, 40, 50, 90, 100}, test.method)
+		if err != nil {
+			t.Fatalf("Unexpected error: %s\n", err)
+		}
+		for i := range values {
+			values[i] = math.Round(values[i]*1e9) / 1e9
+		}
+		if !reflect.DeepEqual(values, test.expected) {
+			t.Errorf("Wrong percentiles for %s: %v != %v\n", test.method, values, test.expected)
+		}
+	}
+	x, _ := Quantile([]float64{1, 2, 3, 4}, 0.5, Nearest)
+	if x != 3 {
+		t.Errorf("Wrong quantile: %v != 3\n", x)
+	}
+	_, err := Percentiles(nil, []float64{50}, Linear)
+	if err == nil {
+		t.Errorf("Expected error\n")
+	}
+}
+
+func TestParsePercentiles(t *testing.T) {
+	percentiles, err := ParsePercentiles("50, p90,P99.9")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s\n", err)
+	}
+	expected := []float64{50, 90, 99.9}
+	if !reflect.DeepEqual(percentiles, expected) {
+		t.Errorf("Wrong percentiles: %v != %v\n", percentiles, expected)
+	}
+	_, err = ParsePercentiles("50,101")
+	if err == nil || err.Error() != "invalid percentile '101', must be between 0 and 100" {
+		t.Errorf("Unexpected error: %v\n", err)
+	}
+}
+
+func TestParseQuantileMethod(t *testing.T) {
+	for _, name := range []string{"linear", "lower", "higher", "nearest", "midpoint", "inverted-cdf", "hazen", "weibull", "median-unbiased"} {
+		m, err := ParseQuantileMethod(name)
+		if err != nil {
+			t.Fatalf("Unexpected error: %s\n", err)
+		}
+		if m.String() != name {
+			t.Errorf("Wrong method: %s != %s\n", m, name)
+		}
+	}
+	_, err := ParseQuantileMethod("r7")
+	if err == nil || err.Error() != "unknown quantile method 'r7'" {
+		t.Errorf("Unexpected error: %v\n", err)
+	}
+}
@@ -0,0 +1,198 @@
+// This file is part of csv-analysis.
+//
+// Copyright (C) 2017  David Gamba Rios
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package stat
+
+import (
+	"math"
+	"sort"
+)
+
+// Skewness - Returns the adjusted Fisher-Pearson sample skewness, G1, of the data, as reported by Excel SKEW and pandas.
+// Returns NaN with less than 3 values or when all the values are equal.
+func Skewness(data []float64) float64 {
+	n := float64(len(data))
+	if n < 3 {
+		return math.NaN()
+	}
+	m2, m3, _ := centralMoments(data)
+	if m2 == 0 {
+		return math.NaN()
+	}
+	g1 := m3 / math.Pow(m2, 1.5)
+	return g1 * math.Sqrt(n*(n-1)) / (n - 2)
+}
+
+// Kurtosis - Returns the sample excess kurtosis, G2, of the data, as reported by Excel KURT and pandas.
+// A normal distribution has an excess kurtosis of 0.
+// Returns NaN with less than 4 values or when all the values are equal.
+func Kurtosis(data []float64) float64 {
+	n := float64(len(data))
+	if n < 4 {
+		return math.NaN()
+	}
+	m2, _, m4 := centralMoments(data)
+	if m2 == 0 {
+		return math.NaN()
+	}
+	g2 := m4/(m2*m2) - 3
+	return ((n+1)*g2 + 6) * (n - 1) / ((n - 2) * (n - 3))
+}
+
+// centralMoments - Returns the second, third and fourth central moments of the data.
+func centralMoments(data []float64) (m2, m3, m4 float64) {
+	var mean float64
+	for _, x := range data {
+		mean += x
+	}
+	n := float64(len(data))
+	mean /= n
+	for _, x := range data {
+		d := x - mean
+		m2 += d * d
+		m3 += d * d * d
+		m4 += d * d * d * d
+	}
+	return m2 / n, m3 / n, m4 / n
+}
+
+// Mode - Returns the most frequent value of the data, the smallest one on ties.
+//
+// With bins > 0, or with bins == 0 when the data has more than sqrt(n) distinct values, like continuous data, the values are binned with Histogram and the mode is the center of the bin with the most values.
+// With bins == 0 the number of bins follows Sturges' rule, ceil(log2(n)) + 1.
+// Returns NaN when there is no data.
+func Mode(data []float64, bins int) float64 {
+	n := len(data)
+	if n == 0 {
+		return math.NaN()
+	}
+	sorted := append([]float64{}, data...)
+	sort.Float64s(sorted)
+	if bins == 0 {
+		distinct := 1
+		for i := 1; i < n; i++ {
+			if sorted[i] != sorted[i-1] {
+				distinct++
+			}
+		}
+		if float64(distinct) <= math.Sqrt(float64(n)) {
+			return exactMode(sorted)
+		}
+		bins = sturges(n)
+	}
+	min, max := sorted[0], sorted[n-1]
+	if min == max {
+		return min
+	}
+	h := Histogram(sorted, bins)
+	best := 0
+	for i, b := range h {
+		if b.Count > h[best].Count {
+			best = i
+		}
+	}
+	return (h[best].Min + h[best].Max) / 2
+}
+
+// exactMode - Returns the most frequent value of the sorted data, the smallest one on ties.
+func exactMode(sorted []float64) float64 {
+	mode, best, count := sorted[0], 0, 0
+	for i, x := range sorted {
+		if i > 0 && x != sorted[i-1] {
+			count = 0
+		}
+		count++
+		if count > best {
+			mode, best = x, count
+		}
+	}
+	return mode
+}
+
+// GeometricMean - Returns the geometric mean of the data.
+// Returns NaN when there is no data or when any value is zero or negative.
+func GeometricMean(data []float64) float64 {
+	if len(data) == 0 {
+		return math.NaN()
+	}
+	var sum float64
+	for _, x := range data {
+		if x <= 0 {
+			return math.NaN()
+		}
+		sum += math.Log(x)
+	}
+	return math.Exp(sum / float64(len(data)))
+}
+
+// HarmonicMean - Returns the harmonic mean of the data.
+// Returns NaN when there is no data or when any value is zero or negative.
+func HarmonicMean(data []float64) float64 {
+	if len(data) == 0 {
+		return math.NaN()
+	}
+	var sum float64
+	for _, x := range data {
+		if x <= 0 {
+			return math.NaN()
+		}
+		sum += 1 / x
+	}
+	return float64(len(data)) / sum
+}
+
+// TrimmedMean - Returns the mean of the data without the lowest and highest trim proportion of the values, with trim between 0 and 0.5.
+// floor(trim * n) values are left out at each end, like scipy.stats.trim_mean.
+// Returns NaN when there is no data left.
+func TrimmedMean(data []float64, trim float64) float64 {
+	sorted, k := trimmed(data, trim)
+	if len(sorted)-2*k <= 0 {
+		return math.NaN()
+	}
+	return mean(sorted[k : len(sorted)-k])
+}
+
+// WinsorizedMean - Returns the mean of the data after replacing the lowest and highest trim proportion of the values with the nearest remaining value, with trim between 0 and 0.5.
+// Returns NaN when there is no data left.
+func WinsorizedMean(data []float64, trim float64) float64 {
+	sorted, k := trimmed(data, trim)
+	n := len(sorted)
+	if n-2*k <= 0 {
+		return math.NaN()
+	}
+	for i := 0; i < k; i++ {
+		sorted[i] = sorted[k]
+		sorted[n-1-i] = sorted[n-1-k]
+	}
+	return mean(sorted)
+}
+
+// trimmed - Returns a sorted copy of the data and the number of values to trim at each end.
+func trimmed(data []float64, trim float64) ([]float64, int) {
+	sorted := append([]float64{}, data...)
+	sort.Float64s(sorted)
+	return sorted, int(math.Floor(trim * float64(len(sorted))))
+}
+
+// mean - Returns the mean of the data.
+func mean(data []float64) float64 {
+	var sum float64
+	for _, x := range data {
+		sum += x
+	}
+	return sum / float64(len(data))
+}
+
+// CoefficientOfVariation - Returns the coefficient of variation, the standard deviation relative to the mean, sd / |mean|.
+// Returns NaN when the mean is zero.
+func CoefficientOfVariation(sd, mean float64) float64 {
+	if mean == 0 {
+		return math.NaN()
+	}
+	return sd / math.Abs(mean)
+}
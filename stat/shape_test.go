@@ -0,0 +1,56 @@
+// This file is part of csv-analysis.
+//
+// Copyright (C) 2017  David Gamba Rios
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+package stat
+
+import (
+	"math"
+	"testing"
+)
+
+func TestShape(t *testing.T) {
+	data := []float64{2, 8, 0, 4, 1, 9, 9, 0}
+	positive := []float64{1, 2, 4, 8}
+	tests := []struct {
+		name     string
+		value    float64
+		expected float64
+	}{
+		// Adjusted Fisher-Pearson G1 and G2, as Excel SKEW and KURT.
+		{"skewness", Skewness(data), 0.33058218040797466},
+		{"kurtosis", Kurtosis(data), -2.098602258096087},
+		{"skewness < 3", Skewness([]float64{1, 2}), math.NaN()},
+		{"kurtosis constant", Kurtosis([]float64{1, 1, 1, 1}), math.NaN()},
+		{"mode exact", Mode([]float64{3, 1, 3, 2, 2, 3, 1, 3, 2, 1}, 0), 3},
+		{"mode tie", Mode([]float64{2, 1, 2, 1, 1, 2, 5, 5, 5}, 0), 1},
+		{"mode binned", Mode([]float64{0.1, 0.2, 1.1, 1.2, 1.3, 1.35, 2.9, 3.0}, 3), 1.55},
+		{"mode auto bins", Mode([]float64{0.1, 0.2, 1.1, 1.2, 1.3, 1.35, 2.9, 3.0}, 0), 1.1875},
+		{"mode empty", Mode(nil, 0), math.NaN()},
+		{"geometric", GeometricMean(positive), math.Pow(64, 0.25)},
+		{"geometric zero", GeometricMean(data), math.NaN()},
+		{"harmonic", HarmonicMean(positive), 4 / (1 + 0.5 + 0.25 + 0.125)},
+		{"harmonic negative", HarmonicMean([]float64{1, -1}), math.NaN()},
+		// floor(0.25 * 8) = 2 values at each end: 1, 2, 4, 8.
+		{"trimmed", TrimmedMean(data, 0.25), 3.75},
+		// 1, 1, 1, 2, 4, 8, 8, 8.
+		{"winsorized", WinsorizedMean(data, 0.25), 4.125},
+		{"trimmed all", TrimmedMean(positive, 0.5), math.NaN()},
+		{"cv", CoefficientOfVariation(2, -4), 0.5},
+		{"cv zero mean", CoefficientOfVariation(2, 0), math.NaN()},
+	}
+	for _, test := range tests {
+		if math.IsNaN(test.expected) {
+			if !math.IsNaN(test.value) {
+				t.Errorf("Wrong %s: %v != NaN\n", test.name, test.value)
+			}
+			continue
+		}
+		if math.Abs(test.value-test.expected) > 1e-9 {
+			t.Errorf("Wrong %s: %v != %v\n", test.name, test.value, test.expected)
+		}
+	}
+}
@@ -8,44 +8,308 @@
 
 /*
 Package stat provides ways to analyse data from one or more files and generate basic statistic analysis.
+
+Describe returns the statistics of a dataset as a Summary and a Formatter writes them, as text with Text or in machine readable formats with Tables.
 */
 package stat
 
 import (
+	"errors"
 	"fmt"
 	"github.com/montanaflynn/stats"
+	"math"
 	"os"
+	"sort"
+	"strings"
 )
 
-// PrintSliceStats -
-func PrintSliceStats(data []float64) {
-	fmt.Printf("Count: %d\n", len(data))
+// ErrEmptyData - Returned when there is no data to describe.
+var ErrEmptyData = errors.New("no data")
+
+// Options - Settings of the statistics.
+type Options struct {
+	// Percentiles to report, between 0 and 100.
+	Percentiles []float64
+	// How percentiles and quartiles between two data points are calculated.
+	Method QuantileMethod
+	// Proportion of the values left out, or replaced, at each end for the trimmed and winsorized means, between 0 and 0.5.
+	Trim float64
+	// Number of histogram bins used to find the mode, 0 selects them automatically, see Mode.
+	ModeBins int
+	// Level of the confidence intervals, between 0 and 1, 0 doesn't calculate them.
+	// Intervals are calculated for the mean, the median and the percentiles, see MeanCI and QuantileCI.
+	Level float64
+	// Statistics, see Summary.Names, with a bootstrap confidence interval, see Bootstrap.
+	Bootstrap []string
+	// Number of bootstrap resamples, 0 uses DefaultResamples, and seed of their random source.
+	Resamples int
+	Seed      int64
+}
+
+// resamples - Returns the number of bootstrap resamples, the default one when not set.
+func (o Options) resamples() int {
+	if o.Resamples == 0 {
+		return DefaultResamples
+	}
+	return o.Resamples
+}
+
+// DefaultPercentiles - Percentiles reported by default.
+var DefaultPercentiles = []float64{50, 90, 95, 99, 99.9}
+
+// DefaultTrim - Proportion trimmed at each end for the trimmed and winsorized means by default.
+const DefaultTrim = 0.1
+
+// DefaultOptions - Returns the default settings, the DefaultPercentiles with Linear interpolation and DefaultTrim.
+func DefaultOptions() Options {
+	return Options{Percentiles: DefaultPercentiles, Method: Linear, Trim: DefaultTrim}
+}
+
+// Summary - Statistics of a dataset.
+type Summary struct {
+	Count    int
+	Min      float64
+	Max      float64
+	Mean     float64
+	SD       float64 // Population standard deviation σ.
+	Variance float64 // Population variance σ².
+	Median   float64
+	MAD      float64 // Median absolute deviation.
+	Sum      float64
+	// Quartiles and interquartile range.
+	Q1, Q3, IQR float64
+	// Percentiles, in the order given in Options.
+	Percentiles []Percentile
+	// Method used to calculate the percentiles and quartiles.
+	Method QuantileMethod
+	// Shape of the distribution.
+	CV       float64 // Coefficient of variation, σ / |mean|, NaN when the mean is zero.
+	Skewness float64 // Sample skewness G1.
+	Kurtosis float64 // Sample excess kurtosis G2.
+	Mode     float64 // Most frequent value, binned for continuous data.
+	// Geometric and harmonic means, NaN unless all the values are positive.
+	GeometricMean, HarmonicMean float64
+	// Trimmed and winsorized means, with Trim proportion of the values left out or replaced at each end.
+	TrimmedMean, WinsorizedMean float64
+	Trim                        float64
+	// Confidence intervals at Level, in order: the mean, the median, the percentiles and the bootstrapped statistics.
+	Level     float64
+	Intervals []Interval
+}
+
+// Percentile - Value of the P percentile, with P between 0 and 100.
+type Percentile struct {
+	P     float64
+	Value float64
+}
+
+// Name - Returns the name of the percentile, like "p99.9".
+func (p Percentile) Name() string {
+	return percentileName(p.P)
+}
+
+// Describe - Returns the statistics of the data.
+// Returns ErrEmptyData when there is no data.
+func Describe(data []float64, o Options) (*Summary, error) {
+	if len(data) == 0 {
+		return nil, ErrEmptyData
+	}
+	s := &Summary{Count: len(data), Method: o.Method, Trim: o.Trim}
 	var d stats.Float64Data = data
-	max, err := d.Max()
-	printError(err)
-	fmt.Printf("Max: %f\n", max)
-	min, err := d.Min()
-	printError(err)
-	fmt.Printf("Min: %f\n", min)
-	mean, err := d.Mean()
-	printError(err)
-	fmt.Printf("Mean: %f\n", mean)
-	sd, err := d.StandardDeviation()
-	printError(err)
-	// TODO: Get better errors
-	fmt.Printf("Standard Deviation σ: %f, %f%%\n", sd, sd*100/mean)
-	variance, err := d.Variance()
-	printError(err)
-	fmt.Printf("Variance σ²: %f\n", variance)
-	median, err := d.Median()
-	printError(err)
-	fmt.Printf("Median: %f\n", median)
-	medianDeviation, err := d.MedianAbsoluteDeviation()
-	printError(err)
-	fmt.Printf("Median Absolute Deviation MAD: %f, %f%%\n", medianDeviation, medianDeviation*100/median)
-	sum, err := d.Sum()
-	printError(err)
-	fmt.Printf("Sum: %f\n", sum)
+	for _, f := range []struct {
+		value *float64
+		f     func() (float64, error)
+	}{
+		{&s.Min, d.Min}, {&s.Max, d.Max}, {&s.Mean, d.Mean}, {&s.SD, d.StandardDeviation}, {&s.Variance, d.Variance},
+		{&s.Median, d.Median}, {&s.MAD, d.MedianAbsoluteDeviation}, {&s.Sum, d.Sum},
+	} {
+		var err error
+		*f.value, err = f.f()
+		if err != nil {
+			return nil, err
+		}
+	}
+	values, err := Percentiles(data, append([]float64{25, 75}, o.Percentiles...), o.Method)
+	if err != nil {
+		return nil, err
+	}
+	s.Q1, s.Q3, s.IQR = values[0], values[1], values[1]-values[0]
+	for i, p := range o.Percentiles {
+		s.Percentiles = append(s.Percentiles, Percentile{P: p, Value: values[i+2]})
+	}
+	s.CV = CoefficientOfVariation(s.SD, s.Mean)
+	s.Skewness = Skewness(data)
+	s.Kurtosis = Kurtosis(data)
+	s.Mode = Mode(data, o.ModeBins)
+	s.GeometricMean = GeometricMean(data)
+	s.HarmonicMean = HarmonicMean(data)
+	s.TrimmedMean = TrimmedMean(data, o.Trim)
+	s.WinsorizedMean = WinsorizedMean(data, o.Trim)
+	if o.Level > 0 {
+		s.Level = o.Level
+		s.Intervals, err = s.intervals(data, o)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// emptySummary - Returns a summary for no data, with NaN statistics.
+func emptySummary(o Options) *Summary {
+	nan := math.NaN()
+	s := &Summary{
+		Min: nan, Max: nan, Mean: nan, SD: nan, Variance: nan, Median: nan, MAD: nan, Sum: nan,
+		Q1: nan, Q3: nan, IQR: nan, Method: o.Method,
+		CV: nan, Skewness: nan, Kurtosis: nan, Mode: nan, GeometricMean: nan, HarmonicMean: nan,
+		TrimmedMean: nan, WinsorizedMean: nan, Trim: o.Trim,
+	}
+	if o.Level > 0 {
+		s.Level = o.Level
+		s.Intervals, _ = s.intervals(nil, o)
+	}
+	for _, p := range o.Percentiles {
+		s.Percentiles = append(s.Percentiles, Percentile{P: p, Value: nan})
+	}
+	return s
+}
+
+// summaryStats - Names of the statistics of a summary, in order, the percentiles go before the IQR.
+var summaryStats = []string{"count", "min", "max", "mean", "sd", "variance", "median", "mad", "sum", "q1", "q3"}
+
+// shapeStats - Names of the shape statistics of a summary, in order, they go after the IQR.
+var shapeStats = []string{"cv", "skewness", "kurtosis", "mode", "geometric_mean", "harmonic_mean", "trimmed_mean", "winsorized_mean"}
+
+// Names - Returns the names of the statistics of the summary, in order, as accepted by Stat.
+// The percentiles are named like "p99.9" and the bounds of the confidence intervals like "mean_ci_lo" and "mean_ci_hi", or "sd_boot_lo" and "sd_boot_hi" for bootstrap intervals.
+func (s *Summary) Names() []string {
+	names := append([]string{}, summaryStats...)
+	for _, p := range s.Percentiles {
+		names = append(names, p.Name())
+	}
+	names = append(names, "iqr")
+	names = append(names, shapeStats...)
+	for _, ci := range s.Intervals {
+		lo, hi := ci.boundNames()
+		names = append(names, lo, hi)
+	}
+	return names
+}
+
+// Stat - Returns the statistic with the given name, see Names.
+func (s *Summary) Stat(name string) (float64, bool) {
+	switch name {
+	case "count":
+		return float64(s.Count), true
+	case "min":
+		return s.Min, true
+	case "max":
+		return s.Max, true
+	case "mean":
+		return s.Mean, true
+	case "sd":
+		return s.SD, true
+	case "variance":
+		return s.Variance, true
+	case "median":
+		return s.Median, true
+	case "mad":
+		return s.MAD, true
+	case "sum":
+		return s.Sum, true
+	case "q1":
+		return s.Q1, true
+	case "q3":
+		return s.Q3, true
+	case "iqr":
+		return s.IQR, true
+	case "cv":
+		return s.CV, true
+	case "skewness":
+		return s.Skewness, true
+	case "kurtosis":
+		return s.Kurtosis, true
+	case "mode":
+		return s.Mode, true
+	case "geometric_mean":
+		return s.GeometricMean, true
+	case "harmonic_mean":
+		return s.HarmonicMean, true
+	case "trimmed_mean":
+		return s.TrimmedMean, true
+	case "winsorized_mean":
+		return s.WinsorizedMean, true
+	}
+	for _, p := range s.Percentiles {
+		if p.Name() == name {
+			return p.Value, true
+		}
+	}
+	for _, ci := range s.Intervals {
+		lo, hi := ci.boundNames()
+		switch name {
+		case lo:
+			return ci.Lo, true
+		case hi:
+			return ci.Hi, true
+		}
+	}
+	return math.NaN(), false
+}
+
+// PrintSliceStats - Prints the statistics of the data to STDOUT as Text, with the DefaultOptions.
+func PrintSliceStats(data []float64) {
+	PrintSliceStatsWithOptions(data, DefaultOptions())
+}
+
+// PrintSliceStatsWithOptions - Prints the statistics of the data to STDOUT as Text, with the given options.
+func PrintSliceStatsWithOptions(data []float64, o Options) {
+	s, err := Describe(data, o)
+	if err != nil {
+		printError(err)
+		return
+	}
+	printError(Text{W: os.Stdout}.WriteSummary(s))
+}
+
+// Row - Statistics of a dataset with the key values that identify it, like its file.
+type Row struct {
+	Keys    []string
+	Summary *Summary
+}
+
+// NewRow - Returns a row with the statistics of the data.
+// The statistics of empty data are NaN.
+func NewRow(keys []string, data []float64, o Options) Row {
+	s, err := Describe(data, o)
+	if err != nil {
+		s = emptySummary(o)
+		s.Count = len(data)
+	}
+	return Row{Keys: keys, Summary: s}
+}
+
+// SortRows - Sorts the rows by the statistic with the given name, see Summary.Names, NaN values go last.
+// The sort is stable, so rows with the same value keep their order.
+func SortRows(rows []Row, by string, descending bool) error {
+	if len(rows) == 0 {
+		return nil
+	}
+	if _, ok := rows[0].Summary.Stat(by); !ok {
+		return fmt.Errorf("unknown statistic '%s', use one of: %s", by, strings.Join(rows[0].Summary.Names(), ", "))
+	}
+	sort.SliceStable(rows, func(i, j int) bool {
+		a, _ := rows[i].Summary.Stat(by)
+		b, _ := rows[j].Summary.Stat(by)
+		if math.IsNaN(a) || math.IsNaN(b) {
+			return !math.IsNaN(a) && math.IsNaN(b)
+		}
+		if descending {
+			return a > b
+		}
+		return a < b
+	})
+	return nil
 }
 
 // printError - prints the given error to STDERR.
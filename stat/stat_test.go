@@ -0,0 +1,83 @@
+// This file is part of csv-analysis.
+//
+// Copyright (C) 2017  David Gamba Rios
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+package stat
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestDescribe(t *testing.T) {
+	s, err := Describe([]float64{4, 1, 3, 2}, Options{Percentiles: []float64{50, 90}})
+	if err != nil {
+		t.Fatalf("Unexpected error: %s\n", err)
+	}
+	expected := &Summary{
+		Count: 4, Min: 1, Max: 4, Mean: 2.5, SD: math.Sqrt(1.25), Variance: 1.25, Median: 2.5, MAD: 1, Sum: 10,
+		Q1: 1.75, Q3: 3.25, IQR: 1.5,
+		Percentiles: []Percentile{{50, 2.5}, {90, 3.7}},
+	}
+	s.Percentiles[1].Value = math.Round(s.Percentiles[1].Value*1e9) / 1e9
+	if math.Abs(s.CV-math.Sqrt(1.25)/2.5) > 1e-9 || math.Abs(s.Kurtosis+1.2) > 1e-9 || s.Mode != 3.5 {
+		t.Errorf("Wrong shape: %+v\n", s)
+	}
+	// Shape statistics are tested in TestShape.
+	s.CV, s.Skewness, s.Kurtosis, s.Mode, s.GeometricMean, s.HarmonicMean, s.TrimmedMean, s.WinsorizedMean = 0, 0, 0, 0, 0, 0, 0, 0
+	if !reflect.DeepEqual(s, expected) {
+		t.Errorf("Wrong summary: %+v != %+v\n", s, expected)
+	}
+	if x, ok := s.Stat("p90"); !ok || x != 3.7 {
+		t.Errorf("Wrong p90: %v\n", x)
+	}
+	if _, ok := s.Stat("p99"); ok {
+		t.Errorf("Unexpected p99\n")
+	}
+	_, err = Describe(nil, DefaultOptions())
+	if err != ErrEmptyData {
+		t.Errorf("Unexpected error: %v\n", err)
+	}
+}
+
+func TestSortRows(t *testing.T) {
+	o := DefaultOptions()
+	rows := []Row{
+		NewRow([]string{"a"}, []float64{1, 2, 3}, o),
+		NewRow([]string{"b"}, nil, o),
+		NewRow([]string{"c"}, []float64{10}, o),
+		NewRow([]string{"d"}, []float64{2}, o),
+	}
+	tests := []struct {
+		by         string
+		descending bool
+		expected   []string
+	}{
+		{"mean", false, []string{"a", "d", "c", "b"}},
+		{"mean", true, []string{"c", "a", "d", "b"}},
+		{"count", true, []string{"a", "c", "d", "b"}},
+		{"p99.9", false, []string{"d", "a", "c", "b"}},
+	}
+	for _, test := range tests {
+		err := SortRows(rows, test.by, test.descending)
+		if err != nil {
+			t.Fatalf("Unexpected error: %s\n", err)
+		}
+		var keys []string
+		for _, row := range rows {
+			keys = append(keys, row.Keys[0])
+		}
+		if !reflect.DeepEqual(keys, test.expected) {
+			t.Errorf("Wrong order for %s: %v != %v\n", test.by, keys, test.expected)
+		}
+	}
+	err := SortRows(rows, "p75", false)
+	if err == nil || err.Error() != "unknown statistic 'p75', use one of: count, min, max, mean, sd, variance, median, mad, sum, q1, q3, p50, p90, p95, p99, p99.9, iqr, "+
+		"cv, skewness, kurtosis, mode, geometric_mean, harmonic_mean, trimmed_mean, winsorized_mean" {
+		t.Errorf("Unexpected error: %v\n", err)
+	}
+}